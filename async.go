@@ -0,0 +1,193 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 异步写入队列满时的处理策略。
+const (
+	// OverflowBlock 阻塞直到队列有空闲位置。
+	OverflowBlock = "block"
+	// OverflowDropNewest 丢弃正在写入的日志。
+	OverflowDropNewest = "drop-newest"
+	// OverflowDropOldest 丢弃队列中最早的日志。
+	OverflowDropOldest = "drop-oldest"
+	// OverflowDropBelowLevel 丢弃低于 AsyncDropLevel 的日志，其余日志阻塞等待。
+	OverflowDropBelowLevel = "drop-below-level"
+
+	defaultAsyncBufferSize = 1024
+)
+
+// asyncDropped 记录所有 logger 因异步队列已满而丢弃的日志条数。
+var asyncDropped uint64
+
+// AsyncDropped 返回自进程启动以来因异步写入队列已满而丢弃的日志条数。
+func AsyncDropped() uint64 {
+	return atomic.LoadUint64(&asyncDropped)
+}
+
+// asyncWriter 将已编码的日志放入有界队列，由后台 goroutine 写入底层的 WriteSyncer。
+type asyncWriter struct {
+	ws        zapcore.WriteSyncer
+	errOutput zapcore.WriteSyncer
+	overflow  string
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  [][]byte
+	head   int
+	count  int
+	closed bool
+	done   chan struct{}
+	// queued 为放入队列的日志条数，finished 为已经写入或丢弃的日志条数，Sync 只等待调用前放入队列的日志
+	queued   uint64
+	finished uint64
+}
+
+func newAsyncWriter(ws, errOutput zapcore.WriteSyncer, size int, overflow string) *asyncWriter {
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+	w := &asyncWriter{
+		ws:        ws,
+		errOutput: errOutput,
+		overflow:  overflow,
+		queue:     make([][]byte, size),
+		done:      make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+
+	return w
+}
+
+// Write 复制 p 并放入队列，队列已满时按 overflow 策略处理。
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		// 关闭后直接同步写入，不丢弃日志
+		return w.ws.Write(entry)
+	}
+
+	for w.count == len(w.queue) {
+		switch w.overflow {
+		case OverflowDropNewest:
+			atomic.AddUint64(&asyncDropped, 1)
+
+			return len(p), nil
+		case OverflowDropOldest:
+			w.queue[w.head] = nil
+			w.head = (w.head + 1) % len(w.queue)
+			w.count--
+			w.finished++
+			atomic.AddUint64(&asyncDropped, 1)
+		default:
+			w.cond.Wait()
+			if w.closed {
+				return w.ws.Write(entry)
+			}
+		}
+	}
+
+	w.queue[(w.head+w.count)%len(w.queue)] = entry
+	w.count++
+	w.queued++
+	w.cond.Broadcast()
+
+	return len(p), nil
+}
+
+// full 返回队列是否已满。
+func (w *asyncWriter) full() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.count == len(w.queue)
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for {
+		for w.count == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.count == 0 {
+			return
+		}
+
+		entry := w.queue[w.head]
+		w.queue[w.head] = nil
+		w.head = (w.head + 1) % len(w.queue)
+		w.count--
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		if _, err := w.ws.Write(entry); err != nil {
+			fmt.Fprintf(w.errOutput, "%v write error: %v\n", time.Now(), err)
+			_ = w.errOutput.Sync()
+		}
+
+		w.mu.Lock()
+		w.finished++
+		w.cond.Broadcast()
+	}
+}
+
+// Sync 等待调用前放入队列的日志全部写入后同步底层的 WriteSyncer，之后放入的日志不会延长等待。
+func (w *asyncWriter) Sync() error {
+	w.mu.Lock()
+	target := w.queued
+	for w.finished < target && !w.closed {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+
+	return w.ws.Sync()
+}
+
+// Close 写入队列中剩余的日志并停止后台 goroutine，可以重复调用。之后的 Write 直接写入底层的 WriteSyncer。
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+
+	return w.ws.Sync()
+}
+
+// asyncCore 在异步队列已满时丢弃低于 dropLevel 的日志，避免它们阻塞更重要的日志。
+type asyncCore struct {
+	zapcore.Core
+	w         *asyncWriter
+	dropLevel zapcore.Level
+}
+
+func (c *asyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &asyncCore{Core: c.Core.With(fields), w: c.w, dropLevel: c.dropLevel}
+}
+
+func (c *asyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.w.overflow == OverflowDropBelowLevel && ent.Level < c.dropLevel && c.w.full() {
+		atomic.AddUint64(&asyncDropped, 1)
+
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,225 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+// gatedWriter 在 gate 关闭前阻塞所有写入。
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	data []string
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{gate: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.data = append(w.data, string(p))
+
+	return len(p), nil
+}
+
+func (w *gatedWriter) Sync() error { return nil }
+
+func (w *gatedWriter) lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.data...)
+}
+
+// refillWriter 每写入一条日志就向 w 再放入一条，使队列一直不为空。
+type refillWriter struct {
+	w    *asyncWriter
+	stop int32
+}
+
+func (r *refillWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&r.stop) == 0 {
+		_, _ = r.w.Write(p)
+	}
+
+	return len(p), nil
+}
+
+func (r *refillWriter) Sync() error { return nil }
+
+// fillAsyncWriter 让后台 goroutine 阻塞在第一条日志上，并写满长度为 2 的队列。
+func fillAsyncWriter(t *testing.T, overflow string) (*asyncWriter, *gatedWriter) {
+	t.Helper()
+	ws := newGatedWriter()
+	w := newAsyncWriter(ws, zapcore.AddSync(os.Stderr), 2, overflow)
+
+	_, _ = w.Write([]byte("1"))
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.count == 0
+	}, time.Second, time.Millisecond)
+	_, _ = w.Write([]byte("2"))
+	_, _ = w.Write([]byte("3"))
+	assert.True(t, w.full())
+
+	return w, ws
+}
+
+func Test_asyncWriter_overflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		overflow    string
+		want        []string
+		wantDropped uint64
+	}{
+		{
+			name:        "drop newest",
+			overflow:    OverflowDropNewest,
+			want:        []string{"1", "2", "3"},
+			wantDropped: 1,
+		},
+		{
+			name:        "drop oldest",
+			overflow:    OverflowDropOldest,
+			want:        []string{"1", "3", "4"},
+			wantDropped: 1,
+		},
+		{
+			name:     "block",
+			overflow: OverflowBlock,
+			want:     []string{"1", "2", "3", "4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := AsyncDropped()
+			w, ws := fillAsyncWriter(t, tt.overflow)
+
+			if tt.overflow == OverflowBlock {
+				written := make(chan struct{})
+				go func() {
+					_, _ = w.Write([]byte("4"))
+					close(written)
+				}()
+				select {
+				case <-written:
+					t.Fatal("write should block when the queue is full")
+				case <-time.After(20 * time.Millisecond):
+				}
+				close(ws.gate)
+				<-written
+			} else {
+				_, _ = w.Write([]byte("4"))
+				close(ws.gate)
+			}
+
+			assert.NoError(t, w.Sync())
+			assert.Equal(t, tt.want, ws.lines())
+			assert.Equal(t, tt.wantDropped, AsyncDropped()-before)
+			assert.NoError(t, w.Close())
+		})
+	}
+}
+
+func Test_asyncWriter_Close(t *testing.T) {
+	ws := newGatedWriter()
+	close(ws.gate)
+	w := newAsyncWriter(ws, zapcore.AddSync(os.Stderr), 0, OverflowBlock)
+	for i := 0; i < 100; i++ {
+		_, _ = w.Write([]byte("x"))
+	}
+
+	assert.NoError(t, w.Close())
+	assert.Len(t, ws.lines(), 100)
+	assert.NoError(t, w.Close())
+
+	_, err := w.Write([]byte("after close"))
+	assert.NoError(t, err)
+	assert.Len(t, ws.lines(), 101)
+}
+
+func Test_asyncWriter_Sync_continuousWrites(t *testing.T) {
+	ws := &refillWriter{}
+	w := newAsyncWriter(ws, zapcore.AddSync(os.Stderr), 0, OverflowBlock)
+	ws.w = w
+	_, _ = w.Write([]byte("x"))
+
+	done := make(chan error)
+	go func() { done <- w.Sync() }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Sync should not wait for entries queued after it was called")
+	}
+
+	atomic.StoreInt32(&ws.stop, 1)
+	assert.NoError(t, w.Close())
+}
+
+func Test_asyncCore_Check(t *testing.T) {
+	before := AsyncDropped()
+	w, ws := fillAsyncWriter(t, OverflowDropBelowLevel)
+	defer func() {
+		close(ws.gate)
+		_ = w.Close()
+	}()
+
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "message"})
+	core := &asyncCore{Core: zapcore.NewCore(enc, w, zapcore.DebugLevel), w: w, dropLevel: zapcore.WarnLevel}
+	core = core.With([]zapcore.Field{String("k", "v")}).(*asyncCore)
+
+	assert.Nil(t, core.Check(zapcore.Entry{Level: zapcore.InfoLevel}, nil))
+	assert.NotNil(t, core.Check(zapcore.Entry{Level: zapcore.ErrorLevel}, nil))
+	assert.Equal(t, uint64(1), AsyncDropped()-before)
+}
+
+func TestNew_async(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	opts := NewOptions()
+	opts.OutputPaths = []string{filename}
+	opts.Async = true
+	opts.AsyncBufferSize = 16
+	opts.DisableSampling = true
+
+	l := New(opts)
+	for i := 0; i < 200; i++ {
+		l.WithName("async").Info("hello")
+	}
+	l.Flush()
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, strings.Count(string(data), "hello"))
+	assert.NoError(t, l.Close())
+}
+
+func Test_newEncoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{name: "console", format: consoleFormat, wantErr: assert.NoError},
+		{name: "json", format: jsonFormat, wantErr: assert.NoError},
+		{name: "text", format: "text", wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newEncoder(tt.format, zapcore.EncoderConfig{})
+			tt.wantErr(t, err)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package log
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultBufferLevel   = zapcore.InfoLevel
+	defaultBufferTrigger = zapcore.ErrorLevel
+	defaultBufferSize    = 1000
+)
+
+// LogBuffer 的状态。
+const (
+	bufferActive = iota
+	bufferFlushed
+	bufferEnded
+)
+
+// bufferedEntry 一条缓存的日志，core 是写入它时使用的未经级别过滤的 Core。
+// fields 是记录时字段列表的副本，字段引用的对象在写出时才编码。
+type bufferedEntry struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// LogBuffer 缓存一个请求范围内低于指定级别的日志，由 NewBufferedContext 创建。
+type LogBuffer struct {
+	level   zapcore.Level
+	trigger zapcore.Level
+	size    int
+
+	mu      sync.Mutex
+	state   int
+	entries []bufferedEntry
+}
+
+// BufferOption 设置 NewBufferedContext 的缓存规则。
+type BufferOption func(b *LogBuffer)
+
+// BufferBelow 设置缓存低于 level 的日志，默认为 InfoLevel，即只缓存 Debug 日志。
+// 缓存的日志不受 logger 日志级别的限制，写出时总会输出。
+func BufferBelow(level Level) BufferOption {
+	return func(b *LogBuffer) { b.level = level }
+}
+
+// FlushOn 设置记录 level 及以上级别的日志时写出所有缓存的日志，默认为 ErrorLevel。
+func FlushOn(level Level) BufferOption {
+	return func(b *LogBuffer) { b.trigger = level }
+}
+
+// BufferSize 设置最多缓存的日志条数，超过后丢弃最早的日志，默认为 1000。
+func BufferSize(size int) BufferOption {
+	return func(b *LogBuffer) {
+		if size > 0 {
+			b.size = size
+		}
+	}
+}
+
+// NewBufferedContext 返回带有缓存 logger 的 ctx，FromContext 会返回这个 logger。
+// 缓存 logger 派生自 ctx 上的 logger，ctx 上没有 logger 时派生自全局 logger。
+//
+// 低于 BufferBelow 级别的日志先缓存在内存中，记录 FlushOn 及以上级别的日志或调用 Commit 时，
+// 缓存的日志按记录的顺序全部写出，之后的日志不再缓存。调用 End 后缓存的日志被丢弃：
+//
+//	ctx, buf := log.NewBufferedContext(ctx)
+//	defer buf.End()
+//
+//	log.FromContext(ctx).Debug("only written if the request fails")
+//
+// 缓存的日志只复制字段列表，不复制字段引用的值，zap.Any、zap.Object、zap.Reflect 等字段引用的可变对象
+// 在写出时才会编码，记录日志后修改这些对象会影响写出的内容。
+//
+// 只有本包创建的 logger 支持缓存，其他 Logger 实现原样放入返回的 ctx 中。
+func NewBufferedContext(ctx context.Context, opts ...BufferOption) (context.Context, *LogBuffer) {
+	b := &LogBuffer{level: defaultBufferLevel, trigger: defaultBufferTrigger, size: defaultBufferSize}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	var logger Logger = std()
+	if l, ok := ctx.Value(logContextKey).(Logger); ok {
+		logger = l
+	}
+	if l, ok := logger.(*zapLogger); ok {
+		logger = l.child(l.zapLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &bufferCore{Core: core, buf: b}
+		})))
+	}
+
+	return logger.WithContext(ctx), b
+}
+
+// Commit 按顺序写出所有缓存的日志，之后的日志不再缓存。
+func (b *LogBuffer) Commit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != bufferActive {
+		return
+	}
+	b.state = bufferFlushed
+	for _, e := range b.entries {
+		_ = e.core.Write(e.entry, e.fields)
+	}
+	b.entries = nil
+}
+
+// End 丢弃所有缓存的日志，之后的日志不再缓存，低于 BufferBelow 级别的日志与其他日志一样按 logger 的日志级别过滤。
+// 已经 Commit 的 LogBuffer 调用 End 不会有任何效果。
+func (b *LogBuffer) End() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != bufferActive {
+		return
+	}
+	b.state = bufferEnded
+	b.entries = nil
+}
+
+func (b *LogBuffer) getState() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// add 缓存一条日志，LogBuffer 已经不再缓存时返回 false。
+func (b *LogBuffer) add(e bufferedEntry) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != bufferActive {
+		return false
+	}
+	if len(b.entries) == b.size {
+		b.entries[0] = bufferedEntry{}
+		b.entries = b.entries[1:]
+	}
+	b.entries = append(b.entries, e)
+
+	return true
+}
+
+// bufferCore 将低于 LogBuffer 级别的日志写入 LogBuffer，Core 通常是 levelCore。
+type bufferCore struct {
+	zapcore.Core
+	buf *LogBuffer
+}
+
+// unfiltered 返回跳过 levelCore 级别过滤的 Core。
+func (c *bufferCore) unfiltered() zapcore.Core {
+	if lc, ok := c.Core.(*levelCore); ok {
+		return lc.Core
+	}
+
+	return c.Core
+}
+
+func (c *bufferCore) Enabled(lvl zapcore.Level) bool {
+	if lvl < c.buf.level && c.buf.getState() != bufferEnded {
+		return true
+	}
+
+	return c.Core.Enabled(lvl)
+}
+
+func (c *bufferCore) With(fields []zapcore.Field) zapcore.Core {
+	return &bufferCore{Core: c.Core.With(fields), buf: c.buf}
+}
+
+func (c *bufferCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= c.buf.trigger {
+		c.buf.Commit()
+	}
+	if ent.Level < c.buf.level {
+		switch c.buf.getState() {
+		case bufferActive:
+			return ce.AddCore(ent, c)
+		case bufferFlushed:
+			return c.unfiltered().Check(ent, ce)
+		}
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// Write 缓存低于 LogBuffer 级别的日志，LogBuffer 已经写出时直接写入。
+func (c *bufferCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	e := bufferedEntry{core: c.unfiltered(), entry: ent, fields: append([]zapcore.Field(nil), fields...)}
+	if c.buf.add(e) || c.buf.getState() == bufferEnded {
+		return nil
+	}
+
+	return e.core.Write(ent, fields)
+}
@@ -0,0 +1,116 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewBufferedContext(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []BufferOption
+		log  func(l Logger, buf *LogBuffer)
+		want []string
+	}{
+		{
+			name: "discard on end",
+			log: func(l Logger, buf *LogBuffer) {
+				l.Debug("debug")
+				l.Info("info")
+				buf.End()
+				l.Debug("after end")
+			},
+			want: []string{"info"},
+		},
+		{
+			name: "flush on error",
+			log: func(l Logger, buf *LogBuffer) {
+				l.Debug("debug 1")
+				l.Info("info")
+				l.WithValues("key", "value").Debug("debug 2")
+				l.Error("error")
+				l.Debug("debug 3")
+				buf.End()
+			},
+			want: []string{"info", "debug 1", "debug 2", "error", "debug 3"},
+		},
+		{
+			name: "commit",
+			log: func(l Logger, buf *LogBuffer) {
+				l.Debug("debug 1")
+				buf.Commit()
+				l.Debug("debug 2")
+				buf.End()
+			},
+			want: []string{"debug 1", "debug 2"},
+		},
+		{
+			name: "buffer below and flush on",
+			opts: []BufferOption{BufferBelow(WarnLevel), FlushOn(WarnLevel)},
+			log: func(l Logger, buf *LogBuffer) {
+				l.Info("info")
+				l.Warn("warn")
+			},
+			want: []string{"info", "warn"},
+		},
+		{
+			name: "buffer size",
+			opts: []BufferOption{BufferSize(2)},
+			log: func(l Logger, buf *LogBuffer) {
+				l.Debug("debug 1")
+				l.Debug("debug 2")
+				l.Debug("debug 3")
+				buf.Commit()
+			},
+			want: []string{"debug 2", "debug 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			logger := NewLogger(zap.New(core))
+			logger.SetLevel(InfoLevel)
+
+			ctx, buf := NewBufferedContext(logger.WithContext(context.Background()), tt.opts...)
+			tt.log(FromContext(ctx), buf)
+
+			var got []string
+			for _, entry := range logs.All() {
+				got = append(got, entry.Message)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewBufferedContext_fields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core))
+	logger.SetLevel(InfoLevel)
+
+	ctx, buf := NewBufferedContext(logger.WithContext(context.WithValue(context.Background(), KeyRequestID, "r1")))
+	FromContext(ctx).WithValues("key", "value").Debug("debug")
+	buf.Commit()
+
+	if assert.Equal(t, 1, logs.Len()) {
+		assert.Equal(t, map[string]interface{}{"request-id": "r1", "key": "value"}, logs.All()[0].ContextMap())
+	}
+}
+
+func TestNewBufferedContext_WithName(t *testing.T) {
+	l := New(NewOptions())
+	l.levels.setLevel("buffered", ErrorLevel)
+
+	ctx, buf := NewBufferedContext(l.WithContext(context.Background()))
+	named := FromContext(ctx).WithName("buffered").(*zapLogger).zapLogger.Core()
+	assert.True(t, named.Enabled(DebugLevel))
+	assert.False(t, named.Enabled(WarnLevel))
+	assert.True(t, named.Enabled(ErrorLevel))
+	buf.End()
+	assert.False(t, named.Enabled(DebugLevel))
+}
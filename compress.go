@@ -0,0 +1,79 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 支持的备份文件压缩格式。
+const (
+	compressNone = ""
+	compressGzip = "gzip"
+	compressZstd = "zstd"
+)
+
+// compressExts 压缩格式对应的文件后缀。
+var compressExts = map[string]string{
+	compressGzip: ".gz",
+	compressZstd: ".zst",
+}
+
+// compressFile 将 src 按 format 压缩为 dst，成功后删除 src。
+func compressFile(src, dst, format string) (err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return fmt.Errorf("failed to open compressed log file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	var zw io.WriteCloser
+	switch format {
+	case compressGzip:
+		zw = gzip.NewWriter(out)
+	case compressZstd:
+		if zw, err = zstd.NewWriter(out); err != nil {
+			_ = out.Close()
+			return fmt.Errorf("failed to create zstd writer: %v", err)
+		}
+	default:
+		_ = out.Close()
+		return fmt.Errorf("not a valid compression format: %q", format)
+	}
+
+	if _, err = io.Copy(zw, f); err != nil {
+		_ = zw.Close()
+		_ = out.Close()
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compressFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "gzip",
+			format:  compressGzip,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "zstd",
+			format:  compressZstd,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "invalid",
+			format:  "lz4",
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "app.log")
+			dst := src + ".out"
+			assert.NoError(t, os.WriteFile(src, []byte("hello world\n"), 0o644))
+
+			if !tt.wantErr(t, compressFile(src, dst, tt.format)) {
+				return
+			}
+			if tt.format != compressGzip && tt.format != compressZstd {
+				_, err := os.Stat(dst)
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+
+			_, err := os.Stat(src)
+			assert.True(t, os.IsNotExist(err))
+
+			f, err := os.Open(dst)
+			assert.NoError(t, err)
+			defer f.Close()
+
+			var r io.Reader
+			if tt.format == compressGzip {
+				r, err = gzip.NewReader(f)
+			} else {
+				r, err = zstd.NewReader(f)
+			}
+			assert.NoError(t, err)
+			data, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, "hello world\n", string(data))
+		})
+	}
+}
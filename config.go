@@ -0,0 +1,353 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的配置文件格式。
+const (
+	ConfigYAML = "yaml"
+	ConfigJSON = "json"
+	ConfigTOML = "toml"
+
+	// configSection 配置文件中日志配置所在的节。
+	configSection = "log"
+	// flagPrefix AddFlags 注册的 flag 名称的前缀。
+	flagPrefix = "log."
+)
+
+// FieldError 一个配置项的错误，Path 为配置项的路径，如 log.level。
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// prefixFieldErrors 为 Options.validate 返回的错误加上配置项路径的前缀，如 level 转换为 log.level。
+func prefixFieldErrors(errs []*FieldError) []error {
+	prefixed := make([]error, 0, len(errs))
+	for _, err := range errs {
+		prefixed = append(prefixed, &FieldError{Path: flagPrefix + err.Path, Err: err.Err})
+	}
+
+	return prefixed
+}
+
+// OptionsError 包含加载或验证 Options 时遇到的所有错误。
+type OptionsError struct {
+	Errors []error
+}
+
+func (e *OptionsError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return "invalid log options: " + strings.Join(msgs, "; ")
+}
+
+// LoadOptions 从配置文件 path 的 log 节中读取 Options，格式由扩展名决定，支持 .yaml、.yml、.json 和 .toml。
+// 详见 OptionsFromReader。
+func LoadOptions(path string, fs ...*pflag.FlagSet) (*Options, error) {
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = ConfigYAML
+	case ".json":
+		format = ConfigJSON
+	case ".toml":
+		format = ConfigTOML
+	default:
+		return nil, fmt.Errorf("unsupported config file: %q", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return OptionsFromReader(f, format, fs...)
+}
+
+// OptionsFromReader 从 r 中读取 format 格式的配置，将其中 log 节的配置项覆盖到 NewOptions 的默认值上：
+//
+//	log:
+//	  level: debug
+//	  output-paths: [stdout, /var/log/app.log]
+//	  sampling-tick: 1s
+//
+// 配置项的名称与 Options 的 json 标签相同。fs 中由 AddFlags 注册并在命令行中设置过的 flag 优先于配置文件。
+// 合并后的 Options 会经过 Validate 验证，未知的配置项、类型错误以及验证失败都会在返回的 *OptionsError 中列出，
+// 每个错误都是带有配置项路径的 *FieldError。需要从环境变量读取配置时，在返回后调用 LoadEnv。
+func OptionsFromReader(r io.Reader, format string, fs ...*pflag.FlagSet) (*Options, error) {
+	raw, err := decodeConfig(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	o := NewOptions()
+	var errs []error
+	section, ok := raw[configSection]
+	if ok && section != nil {
+		if values, ok := section.(map[string]interface{}); ok {
+			errs = append(errs, o.decode(values)...)
+		} else {
+			errs = append(errs, &FieldError{Path: configSection, Err: fmt.Errorf("must be a table, got %T", section)})
+		}
+	}
+	for _, set := range fs {
+		errs = append(errs, o.applyFlags(set)...)
+	}
+	errs = append(errs, prefixFieldErrors(o.validate())...)
+	if len(errs) > 0 {
+		return nil, &OptionsError{Errors: errs}
+	}
+
+	return o, nil
+}
+
+// decodeConfig 将 format 格式的配置解码为 map。
+func decodeConfig(r io.Reader, format string) (map[string]interface{}, error) {
+	raw := map[string]interface{}{}
+	switch strings.ToLower(format) {
+	case ConfigYAML, "yml":
+		if err := yaml.NewDecoder(r).Decode(&raw); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("decode yaml config: %v", err)
+		}
+	case ConfigJSON:
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("decode json config: %v", err)
+		}
+	case ConfigTOML:
+		if _, err := toml.NewDecoder(r).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("decode toml config: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format: %q", format)
+	}
+
+	return raw, nil
+}
+
+// optionFields 返回 Options 中可以配置的字段，key 为 json 标签中的名称。
+func optionFields() map[string]int {
+	t := reflect.TypeOf(Options{})
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+
+	return fields
+}
+
+// decode 将 values 中的配置项写入 o。
+func (o *Options) decode(values map[string]interface{}) []error {
+	fields := optionFields()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	v := reflect.ValueOf(o).Elem()
+	for _, key := range keys {
+		path := flagPrefix + key
+		i, ok := fields[key]
+		if !ok {
+			errs = append(errs, &FieldError{Path: path, Err: fmt.Errorf("unknown field")})
+			continue
+		}
+		if err := setField(v.Field(i), values[key]); err != nil {
+			errs = append(errs, &FieldError{Path: path, Err: err})
+		}
+	}
+
+	return errs
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField 将配置文件中的值 value 转换为 field 的类型后写入 field。
+func setField(field reflect.Value, value interface{}) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	switch {
+	case field.Type() == durationType:
+		switch v := value.(type) {
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+		default:
+			n, err := toInt(value)
+			if err != nil {
+				return fmt.Errorf("must be a duration such as \"1s\", got %v", value)
+			}
+			field.SetInt(n)
+		}
+	case field.Kind() == reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string, got %T", value)
+		}
+		field.SetString(s)
+	case field.Kind() == reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("must be a boolean, got %T", value)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int:
+		n, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case field.Kind() == reflect.Slice:
+		list, err := toStrings(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(list))
+	case field.Kind() == reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("must be a table, got %T", value)
+		}
+		out := make(map[string]string, len(m))
+		for k, v := range m {
+			s, err := toScalarString(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", k, err)
+			}
+			out[k] = s
+		}
+		field.Set(reflect.ValueOf(out))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+func toInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("must be an integer, got %v", value)
+}
+
+// toStrings 将列表或逗号分隔的字符串转换为 []string。
+func toStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return []string{}, nil
+		}
+		return strings.Split(v, ","), nil
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := toScalarString(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("must be a list of strings, got %T", value)
+}
+
+func toScalarString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64, json.Number:
+		return fmt.Sprint(v), nil
+	}
+
+	return "", fmt.Errorf("must be a string, got %T", value)
+}
+
+// applyFlags 将 fs 中由 AddFlags 注册并在命令行中设置过的 flag 写入 o。
+func (o *Options) applyFlags(fs *pflag.FlagSet) []error {
+	if fs == nil {
+		return nil
+	}
+
+	// 使用一个绑定到 o 的 FlagSet 按相同的规则解析 flag 的值
+	target := pflag.NewFlagSet("", pflag.ContinueOnError)
+	o.AddFlags(target)
+
+	var errs []error
+	fs.Visit(func(flag *pflag.Flag) {
+		dst := target.Lookup(flag.Name)
+		if dst == nil || !strings.HasPrefix(flag.Name, flagPrefix) {
+			return
+		}
+		var err error
+		switch v := flag.Value.(type) {
+		case pflag.SliceValue:
+			err = dst.Value.(pflag.SliceValue).Replace(v.GetSlice())
+		default:
+			value := flag.Value.String()
+			if flag.Value.Type() == "stringToString" {
+				value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			}
+			err = dst.Value.Set(value)
+		}
+		if err != nil {
+			errs = append(errs, &FieldError{Path: flag.Name, Err: err})
+		}
+	})
+
+	return errs
+}
@@ -0,0 +1,200 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsFromReader(t *testing.T) {
+	want := NewOptions()
+	want.Level = "debug"
+	want.OutputPaths = []string{"stdout", "/var/log/app.log"}
+	want.LevelOverrides = map[string]string{"db": "warn", "api": "2"}
+	want.SamplingTick = 2 * time.Second
+	want.MaxSize = 100
+	want.Async = true
+
+	tests := []struct {
+		name   string
+		format string
+		config string
+	}{
+		{
+			name:   "yaml",
+			format: ConfigYAML,
+			config: `
+log:
+  level: debug
+  output-paths: [stdout, /var/log/app.log]
+  level-overrides:
+    db: warn
+    api: 2
+  sampling-tick: 2s
+  max-size: 100
+  async: true
+other:
+  key: value
+`,
+		},
+		{
+			name:   "json",
+			format: ConfigJSON,
+			config: `{"log": {
+				"level": "debug",
+				"output-paths": "stdout,/var/log/app.log",
+				"level-overrides": {"db": "warn", "api": 2},
+				"sampling-tick": "2s",
+				"max-size": 100,
+				"async": true
+			}}`,
+		},
+		{
+			name:   "toml",
+			format: ConfigTOML,
+			config: `
+[log]
+level = "debug"
+output-paths = ["stdout", "/var/log/app.log"]
+sampling-tick = "2s"
+max-size = 100
+async = true
+
+[log.level-overrides]
+db = "warn"
+api = 2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OptionsFromReader(strings.NewReader(tt.config), tt.format)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestOptionsFromReader_empty(t *testing.T) {
+	got, err := OptionsFromReader(strings.NewReader(""), ConfigYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, NewOptions(), got)
+}
+
+func TestOptionsFromReader_errors(t *testing.T) {
+	config := `
+log:
+  level: verbose
+  format: xml
+  max-size: big
+  async: "yes"
+  sampling-tick: soon
+  unknown: 1
+`
+	_, err := OptionsFromReader(strings.NewReader(config), ConfigYAML)
+
+	var optsErr *OptionsError
+	if assert.True(t, errors.As(err, &optsErr)) {
+		var paths []string
+		for _, err := range optsErr.Errors {
+			var fieldErr *FieldError
+			if assert.True(t, errors.As(err, &fieldErr)) {
+				paths = append(paths, fieldErr.Path)
+			}
+		}
+		assert.Equal(t, []string{
+			"log.async", "log.max-size", "log.sampling-tick", "log.unknown", "log.level", "log.format",
+		}, paths)
+	}
+	assert.Contains(t, err.Error(), `log.format: not a valid log format: "xml"`)
+}
+
+func TestOptionsFromReader_allValidationErrors(t *testing.T) {
+	config := `
+log:
+  max-size: -1
+  max-backups: -1
+  redact-values: [phone, address]
+  redact-mask: stars
+`
+	_, err := OptionsFromReader(strings.NewReader(config), ConfigYAML)
+
+	var optsErr *OptionsError
+	if assert.True(t, errors.As(err, &optsErr)) {
+		var paths []string
+		for _, err := range optsErr.Errors {
+			var fieldErr *FieldError
+			if assert.True(t, errors.As(err, &fieldErr)) {
+				paths = append(paths, fieldErr.Path)
+			}
+		}
+		assert.Equal(t, []string{
+			"log.max-backups", "log.max-size", "log.redact-values", "log.redact-values", "log.redact-mask",
+		}, paths)
+	}
+}
+
+func TestOptionsFromReader_invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		config string
+	}{
+		{name: "format", format: "ini", config: ""},
+		{name: "syntax", format: ConfigJSON, config: "{"},
+		{name: "section", format: ConfigYAML, config: "log: debug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OptionsFromReader(strings.NewReader(tt.config), tt.format)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOptionsFromReader_flags(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	NewOptions().AddFlags(fs)
+	assert.NoError(t, fs.Parse([]string{
+		"--log.level=warn",
+		"--log.output-paths=stderr",
+		"--log.level-overrides=db=error",
+		"--log.sampling-tick=5s",
+	}))
+
+	config := `
+log:
+  level: debug
+  format: json
+  output-paths: [stdout]
+  sampling-tick: 2s
+`
+	got, err := OptionsFromReader(strings.NewReader(config), ConfigYAML, fs)
+	assert.NoError(t, err)
+	assert.Equal(t, "warn", got.Level)
+	assert.Equal(t, "json", got.Format)
+	assert.Equal(t, []string{"stderr"}, got.OutputPaths)
+	assert.Equal(t, map[string]string{"db": "error"}, got.LevelOverrides)
+	assert.Equal(t, 5*time.Second, got.SamplingTick)
+}
+
+func TestLoadOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	assert.NoError(t, os.WriteFile(path, []byte("log:\n  level: error\n"), 0o644))
+
+	got, err := LoadOptions(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "error", got.Level)
+
+	_, err = LoadOptions(filepath.Join(dir, "config.ini"))
+	assert.Error(t, err)
+	_, err = LoadOptions(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
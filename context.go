@@ -2,6 +2,10 @@ package log
 
 import (
 	"context"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type key int
@@ -10,23 +14,132 @@ const (
 	logContextKey key = iota
 )
 
+// ContextExtractor 从 ctx 中提取需要记录到日志中的字段。
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	extractors = []ContextExtractor{
+		contextValueExtractor(KeyRequestID, "request-id"),
+		contextValueExtractor(KeyEID, "eid"),
+	}
+	extractorsMu sync.RWMutex
+)
+
+// RegisterContextExtractor 注册一个 ContextExtractor，L、FromContext 等方法会按注册顺序调用它们，
+// 并把返回的字段添加到日志中。默认已经注册了 KeyRequestID 和 KeyEID 两个 extractor。
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	extractors = append(extractors, extractor)
+}
+
+// contextValueExtractor 返回一个将 ctx.Value(ctxKey) 记录为 fieldKey 字段的 ContextExtractor。
+func contextValueExtractor(ctxKey interface{}, fieldKey string) ContextExtractor {
+	return func(ctx context.Context) []Field {
+		if value := ctx.Value(ctxKey); value != nil {
+			return []Field{zap.Any(fieldKey, value)}
+		}
+
+		return nil
+	}
+}
+
+// contextFields 返回所有 ContextExtractor 从 ctx 中提取的字段。
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	var fields []Field
+	for _, extractor := range extractors {
+		fields = append(fields, extractor(ctx)...)
+	}
+
+	return fields
+}
+
 // WithContext 返回设置日志值的上下文副本。
 func WithContext(ctx context.Context) context.Context {
-	return std.WithContext(ctx)
+	return std().WithContext(ctx)
 }
 
 func (l *zapLogger) WithContext(ctx context.Context) context.Context {
 	return context.WithValue(ctx, logContextKey, l)
 }
 
-// FromContext 返回 ctx 上的 Logger。
+// FromContext 返回 ctx 上的 Logger，并带上 ContextExtractor 从 ctx 中提取的字段。
 func FromContext(ctx context.Context) Logger {
 	if ctx != nil {
 		logger := ctx.Value(logContextKey)
 		if logger != nil {
-			return logger.(Logger)
+			return withContextFields(logger.(Logger), ctx)
 		}
 	}
 
-	return WithName("Unknown-Context")
+	return withContextFields(WithName("Unknown-Context"), ctx)
+}
+
+// fieldsLogger 由可以直接添加 Field 的 Logger 实现，其他 Logger 通过 WithValues 添加。
+type fieldsLogger interface {
+	// withFields 返回添加了 ctx 中提取的字段的 logger
+	withFields(fields []Field) Logger
+	hasContextFields() bool
+}
+
+// withContextFields 为 logger 添加从 ctx 中提取的字段，没有字段或 logger 已经带有这些字段时原样返回。
+// 本包的 logger 会记录已经添加过字段，之后的 InfoContext 等方法不再重复提取。
+func withContextFields(logger Logger, ctx context.Context) Logger {
+	if l, ok := logger.(fieldsLogger); ok && l.hasContextFields() {
+		return logger
+	}
+	fields := contextFields(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+
+	if l, ok := logger.(fieldsLogger); ok {
+		return l.withFields(fields)
+	}
+
+	return logger.WithValues(fieldKeysAndValues(fields)...)
+}
+
+func (l *zapLogger) withFields(fields []Field) Logger {
+	lg := l.clone()
+	lg.zapLogger = lg.zapLogger.With(fields...)
+	lg.ctxFields = true
+
+	return lg
+}
+
+func (l *zapLogger) hasContextFields() bool {
+	return l.ctxFields
+}
+
+// fieldKeysAndValues 将 fields 转换为 WithValues 使用的键值对。
+func fieldKeysAndValues(fields []Field) []interface{} {
+	keysAndValues := make([]interface{}, 0, 2*len(fields))
+	for _, field := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		for key, value := range enc.Fields {
+			keysAndValues = append(keysAndValues, key, value)
+		}
+	}
+
+	return keysAndValues
+}
+
+// withCallerFields 返回 ctx 中提取的字段与调用方传入的 fields 合并后的结果，ctx 中的字段在前。
+func withCallerFields(ctx context.Context, fields []Field) []Field {
+	ctxFields := contextFields(ctx)
+	if len(ctxFields) == 0 {
+		return fields
+	}
+
+	return append(ctxFields, fields...)
 }
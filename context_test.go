@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestFromContext(t *testing.T) {
@@ -32,6 +35,52 @@ func TestFromContext(t *testing.T) {
 	}
 }
 
+// valuesLogger 记录 WithValues 添加的键值对，用于测试没有实现 fieldsLogger 的 Logger。
+type valuesLogger struct {
+	Logger
+	values []interface{}
+}
+
+func (l *valuesLogger) WithValues(keysAndValues ...interface{}) Logger {
+	return &valuesLogger{Logger: l.Logger, values: append(append([]interface{}(nil), l.values...), keysAndValues...)}
+}
+
+func TestFromContext_withValues(t *testing.T) {
+	ctx := context.WithValue(context.Background(), KeyRequestID, "req-1")
+	ctx = context.WithValue(ctx, KeyEID, 42)
+	ctx = context.WithValue(ctx, logContextKey, &valuesLogger{Logger: New(nil)})
+
+	got, ok := FromContext(ctx).(*valuesLogger)
+	if assert.True(t, ok) {
+		assert.Equal(t, []interface{}{"request-id", "req-1", "eid", int64(42)}, got.values)
+	}
+}
+
+func TestFromContext_InfoContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core))
+	ctx := context.WithValue(context.Background(), KeyRequestID, "req-1")
+	ctx = l.WithContext(ctx)
+
+	FromContext(ctx).InfoContext(ctx, "info")
+	FromContext(ctx).WarnfContext(ctx, "warn %d", 1)
+	l.(*zapLogger).L(ctx).L(ctx).ErrorwContext(ctx, "error", "key", "value")
+	FromContext(ctx).WithName("child").InfoContext(ctx, "child")
+	l.InfoContext(ctx, "plain")
+
+	if assert.Equal(t, 5, logs.Len()) {
+		for _, entry := range logs.All() {
+			var n int
+			for _, f := range entry.Context {
+				if f.Key == "request-id" {
+					n++
+				}
+			}
+			assert.Equal(t, 1, n, entry.Message)
+		}
+	}
+}
+
 func TestFromContext_nil(t *testing.T) {
 	type args struct {
 		ctx context.Context
@@ -98,3 +147,62 @@ func Test_zapLogger_WithContext(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterContextExtractor(t *testing.T) {
+	type tenantKey struct{}
+	extractorsMu.RLock()
+	saved := append([]ContextExtractor(nil), extractors...)
+	extractorsMu.RUnlock()
+	defer func() {
+		extractorsMu.Lock()
+		extractors = saved
+		extractorsMu.Unlock()
+	}()
+
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []Field{String("tenant", tenant)}
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]interface{}
+	}{
+		{
+			name: "empty",
+			ctx:  context.Background(),
+			want: map[string]interface{}{},
+		},
+		{
+			name: "default extractors",
+			ctx:  context.WithValue(context.WithValue(context.Background(), KeyRequestID, "r1"), KeyEID, "e1"),
+			want: map[string]interface{}{"request-id": "r1", "eid": "e1"},
+		},
+		{
+			name: "registered extractor",
+			ctx:  context.WithValue(context.WithValue(context.Background(), KeyRequestID, "r1"), tenantKey{}, "t1"),
+			want: map[string]interface{}{"request-id": "r1", "tenant": "t1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			l := NewLogger(zap.New(core)).(*zapLogger)
+
+			l.L(tt.ctx).Info("l")
+			FromContext(l.WithContext(tt.ctx)).Info("from context")
+
+			assert.Equal(t, 2, logs.Len())
+			for _, entry := range logs.All() {
+				assert.Equal(t, tt.want, entry.ContextMap(), entry.Message)
+			}
+		})
+	}
+}
+
+func Test_contextFields_nil(t *testing.T) {
+	assert.Nil(t, contextFields(nil))
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// LoadEnv 从环境变量中读取配置。变量名由 prefix 和配置项名称组成，转换为大写并将 "-" 替换为 "_"，
+// 如 prefix 为 LOG 时，LOG_LEVEL、LOG_FORMAT 和 LOG_OUTPUT_PATHS 分别对应 Level、Format 和 OutputPaths。
+// 变量值的格式与对应的 flag 相同，列表使用逗号分隔，如 LOG_LEVEL_OVERRIDES=db=debug,api=warn。
+//
+// 配置的优先级从低到高依次为默认值、配置文件、环境变量和 flag：
+//
+//	opts, err := log.LoadOptions("config.yaml")
+//	opts.LoadEnv("LOG", fs)
+//
+// fs 中由 AddFlags 注册并在命令行中设置过的 flag 对应的配置项不会被环境变量覆盖。
+// 也可以在 AddFlags 和解析命令行参数之前调用 LoadEnv，此时命令行参数同样优先于环境变量。
+// 无法解析的环境变量会记录下来，由 Validate 返回，每次调用 LoadEnv 会清除上一次调用记录的错误。
+func (o *Options) LoadEnv(prefix string, fs ...*pflag.FlagSet) {
+	o.envErrs = nil
+
+	// 使用一个绑定到 o 的 FlagSet 按与 flag 相同的规则解析环境变量的值
+	target := pflag.NewFlagSet("", pflag.ContinueOnError)
+	o.AddFlags(target)
+
+	names := make([]string, 0, len(optionFields()))
+	for name := range optionFields() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok := os.LookupEnv(envName(prefix, name))
+		if !ok || flagChanged(fs, flagPrefix+name) {
+			continue
+		}
+		flag := target.Lookup(flagPrefix + name)
+		if flag == nil {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			o.envErrs = append(o.envErrs, &FieldError{
+				Path: name,
+				Err:  fmt.Errorf("invalid value %q for %s: %v", value, envName(prefix, name), err),
+			})
+		}
+	}
+}
+
+// envName 返回配置项 name 对应的环境变量名称。
+func envName(prefix, name string) string {
+	name = strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if prefix = strings.TrimSuffix(prefix, "_"); prefix != "" {
+		name = strings.ToUpper(prefix) + "_" + name
+	}
+
+	return name
+}
+
+func flagChanged(sets []*pflag.FlagSet, name string) bool {
+	for _, fs := range sets {
+		if fs != nil && fs.Changed(name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions_LoadEnv(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	t.Setenv("APP_LOG_FORMAT", "json")
+	t.Setenv("APP_LOG_OUTPUT_PATHS", "stdout,/var/log/app.log")
+	t.Setenv("APP_LOG_LEVEL_OVERRIDES", "db=warn,api=2")
+	t.Setenv("APP_LOG_SAMPLING_TICK", "2s")
+	t.Setenv("APP_LOG_ASYNC", "true")
+	t.Setenv("LOG_MAX_SIZE", "100")
+
+	o := NewOptions()
+	o.LoadEnv("APP_LOG")
+
+	want := NewOptions()
+	want.Level = "debug"
+	want.Format = "json"
+	want.OutputPaths = []string{"stdout", "/var/log/app.log"}
+	want.LevelOverrides = map[string]string{"db": "warn", "api": "2"}
+	want.SamplingTick = 2 * time.Second
+	want.Async = true
+	assert.Equal(t, want, o)
+	assert.Empty(t, o.Validate())
+}
+
+func TestOptions_LoadEnv_errors(t *testing.T) {
+	t.Setenv("LOG_MAX_SIZE", "big")
+	t.Setenv("LOG_ASYNC", "sometimes")
+
+	o := NewOptions()
+	o.LoadEnv("LOG_")
+
+	errs := o.Validate()
+	if assert.Len(t, errs, 2) {
+		assert.Contains(t, errs[0].Error(), `invalid value "sometimes" for LOG_ASYNC`)
+		assert.Contains(t, errs[1].Error(), `invalid value "big" for LOG_MAX_SIZE`)
+	}
+
+	o.LoadEnv("APP_LOG")
+	assert.Empty(t, o.Validate(), "errors of the previous call must be cleared")
+
+	_, err := OptionsFromReader(strings.NewReader(""), ConfigYAML)
+	assert.NoError(t, err, "errors must not leak into other Options")
+}
+
+func TestOptions_LoadEnv_precedence(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("LOG_FORMAT", "json")
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	NewOptions().AddFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"--log.level=error"}))
+
+	o, err := OptionsFromReader(strings.NewReader("log:\n  level: debug\n  format: console\n  name: app\n"), ConfigYAML, fs)
+	assert.NoError(t, err)
+	o.LoadEnv("LOG", fs)
+	assert.Equal(t, "error", o.Level, "flags take precedence over env")
+	assert.Equal(t, "json", o.Format, "env takes precedence over config file")
+	assert.Equal(t, "app", o.Name)
+
+	// 在解析命令行参数之前调用 LoadEnv
+	o = NewOptions()
+	o.LoadEnv("LOG")
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	o.AddFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"--log.level=error"}))
+	assert.Equal(t, "error", o.Level)
+	assert.Equal(t, "json", o.Format)
+}
+
+func Test_envName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		name   string
+		want   string
+	}{
+		{prefix: "LOG", name: "output-paths", want: "LOG_OUTPUT_PATHS"},
+		{prefix: "app_log_", name: "level", want: "APP_LOG_LEVEL"},
+		{prefix: "", name: "max-size", want: "MAX_SIZE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, envName(tt.prefix, tt.name))
+		})
+	}
+}
@@ -0,0 +1,427 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogLogger 是使用 slog.Logger 记录日志的 log.Logger。
+type slogLogger struct {
+	logger *slog.Logger
+	level  zap.AtomicLevel
+	name   string
+	// ctxFields 表示 logger 已经带有 ContextExtractor 从 ctx 中提取的字段
+	ctxFields bool
+}
+
+var _ Logger = &slogLogger{}
+
+// FromSlog 返回使用 logger 记录日志的 Logger，Field 会转换为对应的 slog.Attr，
+// zap.Namespace 和 zap.Object 转换为 slog 的 group，WithName 设置的名称记录在 logger 字段中。
+// 返回的 logger 的初始级别为 logger 启用的最低级别，SetLevel 只能在此之上进一步过滤。
+func FromSlog(logger *slog.Logger) Logger {
+	lvl := zapcore.DebugLevel
+	for lvl < zapcore.FatalLevel && !logger.Enabled(context.Background(), slogLevelOf(lvl)) {
+		lvl++
+	}
+
+	return &slogLogger{logger: logger, level: zap.NewAtomicLevelAt(lvl)}
+}
+
+// slogLevelOf 返回 Zap 级别对应的 slog 日志级别，Debug 到 Error 与 slog 的同名级别对应。
+func slogLevelOf(level zapcore.Level) slog.Level {
+	return slog.Level(level) * (slog.LevelWarn - slog.LevelInfo)
+}
+
+func (l *slogLogger) enabled(ctx context.Context, level zapcore.Level) bool {
+	return l.level.Enabled(level) && l.logger.Enabled(ctx, slogLevelOf(level))
+}
+
+// log 在级别启用时记录一条日志，必须由 Logger 的方法直接调用，以记录正确的调用位置。
+func (l *slogLogger) log(ctx context.Context, level zapcore.Level, msg string, fields []Field, keysAndValues []interface{}) {
+	if !l.enabled(ctx, level) {
+		return
+	}
+
+	l.write(ctx, slogLevelOf(level), msg, fields, keysAndValues)
+}
+
+// logV 记录一条 slog 级别为 level 的 V 日志，必须由 InfoLogger 的方法直接调用，以记录正确的调用位置。
+func (l *slogLogger) logV(ctx context.Context, level slog.Level, msg string, fields []Field, keysAndValues []interface{}) {
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	l.write(ctx, level, msg, fields, keysAndValues)
+}
+
+// write 记录一条日志，必须由 log 或 logV 直接调用。
+func (l *slogLogger) write(ctx context.Context, level slog.Level, msg string, fields []Field, keysAndValues []interface{}) {
+	var pcs [1]uintptr
+	// 跳过 runtime.Callers、write、log 以及调用 log 的方法
+	runtime.Callers(4, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if l.name != "" {
+		r.AddAttrs(slog.String("logger", l.name))
+	}
+	if !l.ctxFields {
+		fields = withCallerFields(ctx, fields)
+	}
+	r.AddAttrs(slogAttrs(fields)...)
+	r.Add(keysAndValues...)
+	_ = l.logger.Handler().Handle(ctx, r)
+}
+
+// slogAttrs 将 Zap 字段转换为 slog.Attr。
+func slogAttrs(fields []Field) []slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	enc := &slogEncoder{}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	return enc.attrs()
+}
+
+func (l *slogLogger) Enabled() bool {
+	return l.enabled(context.Background(), zapcore.InfoLevel)
+}
+
+// V 返回详细级别为 level 的 InfoLogger，按照 logr 的约定使用 slog 级别 -level 记录，
+// logger 启用 Info 级别并且 slog 启用该级别时启用。
+func (l *slogLogger) V(level int) InfoLogger {
+	if level < 0 {
+		level = 0
+	}
+	lvl := slog.Level(-level)
+	if l.level.Enabled(zapcore.InfoLevel) && l.logger.Enabled(context.Background(), lvl) {
+		return &slogInfoLogger{logger: l, level: lvl, verbosity: level}
+	}
+
+	return disabledInfoLogger
+}
+
+func (l *slogLogger) Write(p []byte) (n int, err error) {
+	l.log(context.Background(), zapcore.InfoLevel, string(p), nil, nil)
+
+	return len(p), nil
+}
+
+func (l *slogLogger) WithValues(keysAndValues ...interface{}) Logger {
+	child := *l
+	child.logger = l.logger.With(keysAndValues...)
+
+	return &child
+}
+
+func (l *slogLogger) withFields(fields []Field) Logger {
+	attrs := slogAttrs(fields)
+	args := make([]interface{}, len(attrs))
+	for i, attr := range attrs {
+		args[i] = attr
+	}
+	child := *l
+	child.logger = l.logger.With(args...)
+	child.ctxFields = true
+
+	return &child
+}
+
+func (l *slogLogger) hasContextFields() bool {
+	return l.ctxFields
+}
+
+func (l *slogLogger) WithName(name string) Logger {
+	if name == "" {
+		return l
+	}
+
+	child := *l
+	if l.name == "" {
+		child.name = name
+	} else {
+		child.name = l.name + "." + name
+	}
+
+	return &child
+}
+
+func (l *slogLogger) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, logContextKey, l)
+}
+
+func (l *slogLogger) SetLevel(level Level) {
+	l.level.SetLevel(level)
+}
+
+func (l *slogLogger) GetLevel() Level {
+	return l.level.Level()
+}
+
+// Flush slog.Logger 没有缓冲，什么也不做。
+func (l *slogLogger) Flush() {}
+
+func (l *slogLogger) Debug(msg string, fields ...Field) {
+	l.log(context.Background(), zapcore.DebugLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) Debugf(format string, v ...interface{}) {
+	if l.enabled(context.Background(), zapcore.DebugLevel) {
+		l.log(context.Background(), zapcore.DebugLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.log(context.Background(), zapcore.DebugLevel, msg, nil, keysAndValues)
+}
+
+func (l *slogLogger) Info(msg string, fields ...Field) {
+	l.log(context.Background(), zapcore.InfoLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) Infof(format string, v ...interface{}) {
+	if l.enabled(context.Background(), zapcore.InfoLevel) {
+		l.log(context.Background(), zapcore.InfoLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.log(context.Background(), zapcore.InfoLevel, msg, nil, keysAndValues)
+}
+
+func (l *slogLogger) Warn(msg string, fields ...Field) {
+	l.log(context.Background(), zapcore.WarnLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) Warnf(format string, v ...interface{}) {
+	if l.enabled(context.Background(), zapcore.WarnLevel) {
+		l.log(context.Background(), zapcore.WarnLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.log(context.Background(), zapcore.WarnLevel, msg, nil, keysAndValues)
+}
+
+func (l *slogLogger) Error(msg string, fields ...Field) {
+	l.log(context.Background(), zapcore.ErrorLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) Errorf(format string, v ...interface{}) {
+	if l.enabled(context.Background(), zapcore.ErrorLevel) {
+		l.log(context.Background(), zapcore.ErrorLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.log(context.Background(), zapcore.ErrorLevel, msg, nil, keysAndValues)
+}
+
+func (l *slogLogger) Panic(msg string, fields ...Field) {
+	l.log(context.Background(), zapcore.PanicLevel, msg, fields, nil)
+	panic(msg)
+}
+
+func (l *slogLogger) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.log(context.Background(), zapcore.PanicLevel, msg, nil, nil)
+	panic(msg)
+}
+
+func (l *slogLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	l.log(context.Background(), zapcore.PanicLevel, msg, nil, keysAndValues)
+	panic(msg)
+}
+
+func (l *slogLogger) Fatal(msg string, fields ...Field) {
+	l.log(context.Background(), zapcore.FatalLevel, msg, fields, nil)
+	os.Exit(1)
+}
+
+func (l *slogLogger) Fatalf(format string, v ...interface{}) {
+	l.log(context.Background(), zapcore.FatalLevel, fmt.Sprintf(format, v...), nil, nil)
+	os.Exit(1)
+}
+
+func (l *slogLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.log(context.Background(), zapcore.FatalLevel, msg, nil, keysAndValues)
+	os.Exit(1)
+}
+
+func (l *slogLogger) DebugContext(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.DebugLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) DebugfContext(ctx context.Context, format string, v ...interface{}) {
+	if l.enabled(ctx, zapcore.DebugLevel) {
+		l.log(ctx, zapcore.DebugLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) DebugwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, zapcore.DebugLevel, msg, nil, keysAndValues)
+}
+
+func (l *slogLogger) InfoContext(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.InfoLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) InfofContext(ctx context.Context, format string, v ...interface{}) {
+	if l.enabled(ctx, zapcore.InfoLevel) {
+		l.log(ctx, zapcore.InfoLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) InfowContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, zapcore.InfoLevel, msg, nil, keysAndValues)
+}
+
+func (l *slogLogger) WarnContext(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.WarnLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) WarnfContext(ctx context.Context, format string, v ...interface{}) {
+	if l.enabled(ctx, zapcore.WarnLevel) {
+		l.log(ctx, zapcore.WarnLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) WarnwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, zapcore.WarnLevel, msg, nil, keysAndValues)
+}
+
+func (l *slogLogger) ErrorContext(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, zapcore.ErrorLevel, msg, fields, nil)
+}
+
+func (l *slogLogger) ErrorfContext(ctx context.Context, format string, v ...interface{}) {
+	if l.enabled(ctx, zapcore.ErrorLevel) {
+		l.log(ctx, zapcore.ErrorLevel, fmt.Sprintf(format, v...), nil, nil)
+	}
+}
+
+func (l *slogLogger) ErrorwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, zapcore.ErrorLevel, msg, nil, keysAndValues)
+}
+
+// slogInfoLogger 是 slogLogger 在特定详细级别上的 InfoLogger。
+type slogInfoLogger struct {
+	logger    *slogLogger
+	level     slog.Level
+	verbosity int
+}
+
+func (l *slogInfoLogger) Enabled() bool { return true }
+
+func (l *slogInfoLogger) Info(msg string, fields ...Field) {
+	l.logger.logV(context.Background(), l.level, msg, l.fields(fields), nil)
+}
+
+func (l *slogInfoLogger) Infof(format string, args ...interface{}) {
+	l.logger.logV(context.Background(), l.level, fmt.Sprintf(format, args...), l.fields(nil), nil)
+}
+
+func (l *slogInfoLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.logger.logV(context.Background(), l.level, msg, l.fields(nil), keysAndValues)
+}
+
+func (l *slogInfoLogger) fields(fields []Field) []Field {
+	return append([]Field{Int(verbosityKey, l.verbosity)}, fields...)
+}
+
+// slogNamespace 一个已经打开的 namespace 以及打开之前已经添加的字段。
+type slogNamespace struct {
+	key    string
+	before []slog.Attr
+}
+
+// slogEncoder 是将 Zap 字段转换为 slog.Attr 的 zapcore.ObjectEncoder。
+type slogEncoder struct {
+	current    []slog.Attr
+	namespaces []slogNamespace
+}
+
+// attrs 返回添加的所有字段，namespace 之后的字段放在以 namespace 为名称的 group 中。
+func (e *slogEncoder) attrs() []slog.Attr {
+	attrs := e.current
+	for i := len(e.namespaces) - 1; i >= 0; i-- {
+		ns := e.namespaces[i]
+		attrs = append(ns.before, slog.Attr{Key: ns.key, Value: slog.GroupValue(attrs...)})
+	}
+
+	return attrs
+}
+
+func (e *slogEncoder) add(attr slog.Attr) {
+	e.current = append(e.current, attr)
+}
+
+func (e *slogEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	// slog 没有数组类型，借助 MapObjectEncoder 将数组转换为 []interface{}
+	enc := zapcore.NewMapObjectEncoder()
+	err := enc.AddArray(key, marshaler)
+	e.add(slog.Any(key, enc.Fields[key]))
+
+	return err
+}
+
+func (e *slogEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	enc := &slogEncoder{}
+	err := marshaler.MarshalLogObject(enc)
+	e.add(slog.Attr{Key: key, Value: slog.GroupValue(enc.attrs()...)})
+
+	return err
+}
+
+func (e *slogEncoder) AddBinary(key string, value []byte) { e.add(slog.Any(key, value)) }
+func (e *slogEncoder) AddByteString(key string, value []byte) {
+	e.add(slog.String(key, string(value)))
+}
+func (e *slogEncoder) AddBool(key string, value bool) { e.add(slog.Bool(key, value)) }
+func (e *slogEncoder) AddComplex128(key string, value complex128) {
+	e.add(slog.Any(key, value))
+}
+func (e *slogEncoder) AddComplex64(key string, value complex64) { e.add(slog.Any(key, value)) }
+func (e *slogEncoder) AddDuration(key string, value time.Duration) {
+	e.add(slog.Duration(key, value))
+}
+func (e *slogEncoder) AddFloat64(key string, value float64) { e.add(slog.Float64(key, value)) }
+func (e *slogEncoder) AddFloat32(key string, value float32) {
+	e.add(slog.Float64(key, float64(value)))
+}
+func (e *slogEncoder) AddInt(key string, value int)         { e.add(slog.Int(key, value)) }
+func (e *slogEncoder) AddInt64(key string, value int64)     { e.add(slog.Int64(key, value)) }
+func (e *slogEncoder) AddInt32(key string, value int32)     { e.add(slog.Int64(key, int64(value))) }
+func (e *slogEncoder) AddInt16(key string, value int16)     { e.add(slog.Int64(key, int64(value))) }
+func (e *slogEncoder) AddInt8(key string, value int8)       { e.add(slog.Int64(key, int64(value))) }
+func (e *slogEncoder) AddString(key, value string)          { e.add(slog.String(key, value)) }
+func (e *slogEncoder) AddTime(key string, value time.Time)  { e.add(slog.Time(key, value)) }
+func (e *slogEncoder) AddUint(key string, value uint)       { e.add(slog.Uint64(key, uint64(value))) }
+func (e *slogEncoder) AddUint64(key string, value uint64)   { e.add(slog.Uint64(key, value)) }
+func (e *slogEncoder) AddUint32(key string, value uint32)   { e.add(slog.Uint64(key, uint64(value))) }
+func (e *slogEncoder) AddUint16(key string, value uint16)   { e.add(slog.Uint64(key, uint64(value))) }
+func (e *slogEncoder) AddUint8(key string, value uint8)     { e.add(slog.Uint64(key, uint64(value))) }
+func (e *slogEncoder) AddUintptr(key string, value uintptr) { e.add(slog.Uint64(key, uint64(value))) }
+
+func (e *slogEncoder) AddReflected(key string, value interface{}) error {
+	e.add(slog.Any(key, value))
+
+	return nil
+}
+
+func (e *slogEncoder) OpenNamespace(key string) {
+	e.namespaces = append(e.namespaces, slogNamespace{key: key, before: e.current})
+	e.current = nil
+}
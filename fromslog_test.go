@@ -0,0 +1,207 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func newSlogBuffer(level slog.Level) (*slog.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	h := slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	return slog.New(h), buf
+}
+
+func decodeSlogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		delete(record, slog.SourceKey)
+		records = append(records, record)
+	}
+
+	return records
+}
+
+type slogTestUser struct {
+	Name string
+}
+
+func (u slogTestUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	return nil
+}
+
+func TestFromSlog(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l Logger)
+		want map[string]interface{}
+	}{
+		{
+			name: "fields",
+			log: func(l Logger) {
+				l.Info("msg", String("string", "value"), Int("int", 1), Bool("bool", true), Err(errors.New("boom")))
+			},
+			want: map[string]interface{}{
+				"level": "INFO", "msg": "msg", "string": "value", "int": float64(1), "bool": true, "error": "boom",
+			},
+		},
+		{
+			name: "namespace and object",
+			log: func(l Logger) {
+				l.Warn("msg", String("a", "1"), zap.Namespace("ns"), zap.Object("user", slogTestUser{Name: "n"}))
+			},
+			want: map[string]interface{}{
+				"level": "WARN", "msg": "msg", "a": "1",
+				"ns": map[string]interface{}{"user": map[string]interface{}{"name": "n"}},
+			},
+		},
+		{
+			name: "array",
+			log: func(l Logger) {
+				l.Error("msg", zap.Strings("s", []string{"a", "b"}))
+			},
+			want: map[string]interface{}{"level": "ERROR", "msg": "msg", "s": []interface{}{"a", "b"}},
+		},
+		{
+			name: "with name and values",
+			log: func(l Logger) {
+				l.WithName("a").WithName("b").WithValues("key", "value").Infow("msg", "other", 2)
+			},
+			want: map[string]interface{}{"level": "INFO", "msg": "msg", "logger": "a.b", "key": "value", "other": float64(2)},
+		},
+		{
+			name: "format",
+			log: func(l Logger) {
+				l.Debugf("msg %d", 1)
+			},
+			want: map[string]interface{}{"level": "DEBUG", "msg": "msg 1"},
+		},
+		{
+			name: "context",
+			log: func(l Logger) {
+				l.InfoContext(context.WithValue(context.Background(), KeyRequestID, "r1"), "msg")
+			},
+			want: map[string]interface{}{"level": "INFO", "msg": "msg", "request-id": "r1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sl, buf := newSlogBuffer(slog.LevelDebug)
+			tt.log(FromSlog(sl))
+
+			records := decodeSlogLines(t, buf)
+			if assert.Len(t, records, 1) {
+				assert.Equal(t, tt.want, records[0])
+			}
+		})
+	}
+}
+
+func TestFromSlog_level(t *testing.T) {
+	sl, buf := newSlogBuffer(slog.LevelInfo)
+	l := FromSlog(sl)
+	assert.Equal(t, InfoLevel, l.GetLevel())
+	assert.True(t, l.Enabled())
+
+	l.Debug("debug")
+	l.SetLevel(WarnLevel)
+	l.WithName("child").Info("info")
+	l.Warn("warn")
+
+	records := decodeSlogLines(t, buf)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "warn", records[0]["msg"])
+	}
+}
+
+func TestFromSlog_V(t *testing.T) {
+	sl, buf := newSlogBuffer(slog.LevelDebug)
+	l := FromSlog(sl)
+
+	l.V(0).Info("enabled")
+	l.V(4).Infow("debug", "k", "v")
+	assert.False(t, l.V(10).Enabled())
+	l.V(10).Info("disabled")
+
+	records := decodeSlogLines(t, buf)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "enabled", records[0]["msg"])
+		assert.Equal(t, "INFO", records[0]["level"])
+		assert.Equal(t, float64(0), records[0]["v"])
+		assert.Equal(t, "DEBUG", records[1]["level"])
+		assert.Equal(t, float64(4), records[1]["v"])
+		assert.Equal(t, "v", records[1]["k"])
+	}
+}
+
+func TestFromSlog_source(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := FromSlog(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{AddSource: true})))
+
+	l.Info("info")
+	l.Infof("infof")
+	l.V(0).Info("v")
+	l.ErrorwContext(context.Background(), "errorw")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+	for _, line := range lines {
+		assert.Contains(t, line, "fromslog_test.go")
+	}
+}
+
+func TestFromSlog_FromContext(t *testing.T) {
+	sl, buf := newSlogBuffer(slog.LevelDebug)
+	l := FromSlog(sl).WithName("ctx")
+
+	ctx := context.WithValue(context.Background(), KeyRequestID, "req-1")
+	FromContext(l.WithContext(ctx)).Info("msg")
+	// FromContext 已经添加了 ctx 中的字段，InfoContext 不再重复添加
+	FromContext(l.WithContext(ctx)).InfoContext(ctx, "msg")
+	assert.Equal(t, 2, strings.Count(buf.String(), `"request-id"`))
+
+	records := decodeSlogLines(t, buf)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "ctx", records[0]["logger"])
+		assert.Equal(t, "req-1", records[0]["request-id"])
+	}
+}
+
+func TestFromSlog_Panic(t *testing.T) {
+	sl, buf := newSlogBuffer(slog.LevelDebug)
+	l := FromSlog(sl)
+
+	assert.PanicsWithValue(t, "panic 1", func() { l.Panicf("panic %d", 1) })
+	records := decodeSlogLines(t, buf)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "panic 1", records[0]["msg"])
+	}
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// global 保存全局 logger，类型为 *zapLogger。包级别的函数每次调用时原子地读取它，
+// 因此可以在其他 goroutine 记录日志的同时替换全局 logger。
+var global atomic.Value
+
+func init() {
+	global.Store(New(NewOptions()))
+}
+
+// std 返回当前的全局 logger。
+func std() *zapLogger {
+	return global.Load().(*zapLogger)
+}
+
+// ReplaceGlobal 将全局 logger 替换为 l，返回恢复原来的全局 logger 的函数，可以在其他 goroutine 记录日志时安全调用。
+// l 为 nil 或值为 nil 的指针时全局 logger 不再输出任何日志。
+//
+// 本包创建的 logger 直接作为全局 logger 使用；其他 Logger 实现通过适配器接收日志，
+// 包级别的函数记录的调用位置由该实现决定，可能不准确。
+//
+//	restore := log.ReplaceGlobal(logger)
+//	defer restore()
+func ReplaceGlobal(l Logger) (restore func()) {
+	prev := std()
+	global.Store(globalLogger(l))
+
+	return func() { global.Store(prev) }
+}
+
+// globalLogger 将 l 转换为可以作为全局 logger 的 *zapLogger。
+func globalLogger(l Logger) *zapLogger {
+	if isNilLogger(l) {
+		return NewLogger(zap.NewNop()).(*zapLogger)
+	}
+	if l, ok := l.(*zapLogger); ok {
+		return l
+	}
+
+	logger := NewLogger(zap.New(&loggerCore{logger: l})).(*zapLogger)
+	if c, ok := l.(interface{ Close() error }); ok {
+		logger.closer = c.Close
+	}
+
+	return logger
+}
+
+// isNilLogger 返回 l 是否为 nil 或值为 nil 的指针，如 (*zapLogger)(nil)。
+func isNilLogger(l Logger) bool {
+	if l == nil {
+		return true
+	}
+	v := reflect.ValueOf(l)
+
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// loggerCore 将日志转发给 Logger 的 zapcore.Core。
+type loggerCore struct {
+	logger Logger
+	fields []zapcore.Field
+}
+
+func (c *loggerCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= c.logger.GetLevel()
+}
+
+func (c *loggerCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+
+	return &loggerCore{logger: c.logger, fields: append(all, fields...)}
+}
+
+func (c *loggerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+// Write 按级别调用 Logger 对应的方法，Panic 和 Fatal 级别的日志使用 Error 记录，之后由 zap 负责 panic 或退出。
+func (c *loggerCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	logger := c.logger
+	if ent.LoggerName != "" {
+		logger = logger.WithName(ent.LoggerName)
+	}
+	if len(c.fields) > 0 {
+		fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	}
+
+	switch {
+	case ent.Level <= zapcore.DebugLevel:
+		logger.Debug(ent.Message, fields...)
+	case ent.Level == zapcore.InfoLevel:
+		logger.Info(ent.Message, fields...)
+	case ent.Level == zapcore.WarnLevel:
+		logger.Warn(ent.Message, fields...)
+	default:
+		logger.Error(ent.Message, fields...)
+	}
+
+	return nil
+}
+
+func (c *loggerCore) Sync() error {
+	c.logger.Flush()
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestReplaceGlobal(t *testing.T) {
+	prev := std()
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)))
+
+	restore := ReplaceGlobal(l)
+	Info("replaced", String("k", "v"))
+	Debugf("debug %d", 1)
+	restore()
+	Info("restored")
+
+	assert.Same(t, prev, std())
+	if assert.Equal(t, 2, logs.Len()) {
+		entry := logs.All()[0]
+		assert.Equal(t, "replaced", entry.Message)
+		assert.Equal(t, map[string]interface{}{"k": "v"}, entry.ContextMap())
+		assert.Contains(t, entry.Caller.File, "/global_test.go")
+		assert.Equal(t, "debug 1", logs.All()[1].Message)
+	}
+}
+
+func TestReplaceGlobal_nil(t *testing.T) {
+	defer ReplaceGlobal(nil)()
+
+	assert.NotPanics(t, func() { Info("dropped") })
+	assert.False(t, V(0).Enabled())
+}
+
+func TestReplaceGlobal_logger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	inner := NewLogger(zap.New(core))
+	inner.SetLevel(InfoLevel)
+	defer ReplaceGlobal(struct{ Logger }{inner})()
+
+	Debug("dropped")
+	WithValues("k", "v").Info("info")
+	WithName("db").Warn("warn")
+	assert.Panics(t, func() { Panic("panic") })
+
+	got := logs.AllUntimed()
+	if assert.Len(t, got, 3) {
+		assert.Equal(t, zapcore.InfoLevel, got[0].Level)
+		assert.Equal(t, map[string]interface{}{"k": "v"}, got[0].ContextMap())
+		assert.Equal(t, "db", got[1].LoggerName)
+		assert.Equal(t, zapcore.WarnLevel, got[1].Level)
+		assert.Equal(t, zapcore.ErrorLevel, got[2].Level)
+		assert.Equal(t, "panic", got[2].Message)
+	}
+}
+
+func TestInit_previous(t *testing.T) {
+	prev := std()
+	defer ReplaceGlobal(prev)()
+
+	restore := Init(NewOptions())
+	assert.NotSame(t, prev, std())
+	restore()
+	assert.Same(t, prev, std())
+}
+
+func TestReplaceGlobal_typedNil(t *testing.T) {
+	defer ReplaceGlobal(std())()
+
+	assert.NotPanics(t, func() {
+		ReplaceGlobal((*zapLogger)(nil))
+		Info("dropped")
+		ReplaceGlobal((*struct{ Logger })(nil))
+		Info("dropped")
+	})
+}
+
+func TestReplaceGlobal_race(t *testing.T) {
+	defer ReplaceGlobal(std())()
+
+	core, _ := observer.New(zapcore.DebugLevel)
+	l := NewLogger(zap.New(core))
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					Debug("debug")
+					V(1).Info("v")
+					L(nil).Info("l")
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		ReplaceGlobal(l)
+		ReplaceGlobal(NewLogger(zap.New(core)))
+	}
+	close(done)
+	wg.Wait()
+}
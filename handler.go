@@ -0,0 +1,198 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxLevelPayloadSize 修改日志级别的请求 body 的最大字节数。
+const maxLevelPayloadSize = 64 << 10
+
+// levelHandler 是查看和修改日志级别的 http.Handler。
+type levelHandler struct {
+	logger  func() *zapLogger
+	mu      sync.Mutex
+	reverts map[string]*levelRevert
+}
+
+// levelRevert 一个等待到期后恢复的日志级别。
+type levelRevert struct {
+	timer    *time.Timer
+	level    zapcore.Level
+	explicit bool
+}
+
+// levelPayload 修改日志级别的请求内容。
+type levelPayload struct {
+	Level  *zapcore.Level `json:"level"`
+	Logger string         `json:"logger"`
+	TTL    string         `json:"ttl"`
+}
+
+// levelResponse 日志级别的查询结果。
+type levelResponse struct {
+	Level   zapcore.Level            `json:"level"`
+	Loggers map[string]zapcore.Level `json:"loggers"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// LevelHandler 返回一个查看和修改全局 logger 日志级别的 http.Handler。
+//
+// GET 请求返回全局 logger 的级别，以及 WithName 创建过的每个命名 logger 和按名称设置的前缀当前生效的级别，
+// 命名 logger 最多记录 1024 个：
+//
+//	{"level":"info","loggers":{"api.handler":"debug"}}
+//
+// logger 的名称与 Options.LevelOverrides 相同，不包括 Options.Name 设置的前缀。
+//
+// PUT 或 POST 请求修改日志级别，logger 为空时修改全局级别，ttl 不为空时到期自动恢复原来的级别，
+// 恢复的是到期时全局 logger 上的级别。
+// Content-Type 为 application/x-www-form-urlencoded 时从表单或 query 参数读取：
+//
+//	curl -X PUT localhost:8080/log/level -d level=debug -d logger=api -d ttl=10m
+//
+// 其他 Content-Type 按 JSON 解析：
+//
+//	curl -X PUT localhost:8080/log/level -H "Content-Type: application/json" -d '{"level":"debug","ttl":"10m"}'
+func LevelHandler() http.Handler {
+	return &levelHandler{logger: std, reverts: make(map[string]*levelRevert)}
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxLevelPayloadSize)
+		if err := h.update(r); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = enc.Encode(errorResponse{Error: err.Error()})
+
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_ = enc.Encode(errorResponse{Error: "Only GET, PUT and POST are supported."})
+
+		return
+	}
+
+	l := h.logger()
+	resp := levelResponse{Level: l.level.Level(), Loggers: map[string]zapcore.Level{}}
+	if l.levels != nil {
+		resp.Loggers = l.levels.levels()
+	}
+	_ = enc.Encode(resp)
+}
+
+// registry 返回 handler 管理的 logger 的 levelRegistry，logger 不支持按名称设置级别时 ok 为 false，
+// 返回的 registry 只能修改全局级别。
+func (h *levelHandler) registry() (levels *levelRegistry, ok bool) {
+	l := h.logger()
+	if l.levels == nil {
+		return newLevelRegistry(l.level), false
+	}
+
+	return l.levels, true
+}
+
+func (h *levelHandler) update(r *http.Request) error {
+	payload, err := decodeLevelPayload(r)
+	if err != nil {
+		return err
+	}
+	if payload.Level == nil {
+		return errors.New("must specify logging level")
+	}
+
+	var ttl time.Duration
+	if payload.TTL != "" {
+		if ttl, err = time.ParseDuration(payload.TTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl: %q", payload.TTL)
+		}
+	}
+
+	levels, ok := h.registry()
+	if !ok && payload.Logger != "" {
+		return errors.New("the global logger does not support per-logger levels")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	name := payload.Logger
+	prev, explicit := levels.setLevel(name, *payload.Level)
+	// 新的修改会取消同一 logger 上尚未到期的恢复，并沿用最初的级别作为恢复目标
+	if pending, ok := h.reverts[name]; ok {
+		pending.timer.Stop()
+		delete(h.reverts, name)
+		prev, explicit = pending.level, pending.explicit
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	revert := &levelRevert{level: prev, explicit: explicit}
+	revert.timer = time.AfterFunc(ttl, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.reverts[name] != revert {
+			return
+		}
+		delete(h.reverts, name)
+		// 到期时重新获取 logger，全局 logger 在此期间被替换时恢复的是新 logger 的级别
+		levels, ok := h.registry()
+		if !ok && name != "" {
+			return
+		}
+		if revert.explicit {
+			levels.setLevel(name, revert.level)
+		} else {
+			levels.resetLevel(name)
+		}
+	})
+	h.reverts[name] = revert
+
+	return nil
+}
+
+func decodeLevelPayload(r *http.Request) (*levelPayload, error) {
+	var payload levelPayload
+	// Content-Type 可能带有 charset 等参数
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		if lvl := r.FormValue("level"); lvl != "" {
+			var level zapcore.Level
+			if err := level.UnmarshalText([]byte(lvl)); err != nil {
+				return nil, err
+			}
+			payload.Level = &level
+		}
+		payload.Logger = r.FormValue("logger")
+		payload.TTL = r.FormValue("ttl")
+
+		return &payload, nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("request body must be well-formed JSON: %v", err)
+	}
+
+	return &payload, nil
+}
@@ -0,0 +1,182 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer SetLevel(GetLevel())
+	defer std().levels.resetLevel("handler-test")
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantCode    int
+		wantLevel   Level
+		wantLoggers map[string]Level
+	}{
+		{
+			name:      "get",
+			method:    http.MethodGet,
+			wantCode:  http.StatusOK,
+			wantLevel: InfoLevel,
+		},
+		{
+			name:        "put json",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{"level":"warn"}`,
+			wantCode:    http.StatusOK,
+			wantLevel:   WarnLevel,
+		},
+		{
+			name:        "post form with logger",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "level=debug&logger=handler-test",
+			wantCode:    http.StatusOK,
+			wantLevel:   WarnLevel,
+			wantLoggers: map[string]Level{"handler-test": DebugLevel},
+		},
+		{
+			name:        "post form with charset",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded; charset=UTF-8",
+			body:        "level=warn",
+			wantCode:    http.StatusOK,
+			wantLevel:   WarnLevel,
+			wantLoggers: map[string]Level{"handler-test": DebugLevel},
+		},
+		{
+			name:        "body too large",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{"level":"debug","logger":"` + strings.Repeat("a", maxLevelPayloadSize) + `"}`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "invalid level",
+			method:      http.MethodPut,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "level=illegal",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "missing level",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{}`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "invalid ttl",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{"level":"debug","ttl":"forever"}`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:     "method not allowed",
+			method:   http.MethodDelete,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+	SetLevel(InfoLevel)
+	h := LevelHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/log/level", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var resp levelResponse
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.wantLevel, resp.Level)
+			for name, level := range tt.wantLoggers {
+				assert.Equal(t, level, resp.Loggers[name])
+			}
+		})
+	}
+}
+
+func TestLevelHandler_ttl(t *testing.T) {
+	defer SetLevel(GetLevel())
+	SetLevel(InfoLevel)
+	named := WithName("handler-ttl")
+
+	h := LevelHandler()
+	put := func(body string) {
+		req := httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	put(`{"level":"debug","ttl":"50ms"}`)
+	put(`{"level":"error","logger":"handler-ttl","ttl":"50ms"}`)
+	put(`{"level":"warn","logger":"handler-ttl","ttl":"50ms"}`)
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.False(t, named.(*zapLogger).zapLogger.Core().Enabled(InfoLevel))
+
+	assert.Eventually(t, func() bool {
+		return GetLevel() == InfoLevel
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return named.(*zapLogger).zapLogger.Core().Enabled(InfoLevel)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLevelHandler_namedLoggers(t *testing.T) {
+	l := New(nil)
+	l.WithName("api").WithName("handler")
+	l.levels.setLevel("db", DebugLevel)
+	h := &levelHandler{logger: func() *zapLogger { return l }, reverts: make(map[string]*levelRevert)}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+
+	var resp levelResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, map[string]Level{"api": InfoLevel, "api.handler": InfoLevel, "db": DebugLevel}, resp.Loggers)
+}
+
+func TestLevelHandler_ttlReplacedLogger(t *testing.T) {
+	var current atomic.Value
+	current.Store(New(nil))
+	h := &levelHandler{
+		logger:  func() *zapLogger { return current.Load().(*zapLogger) },
+		reverts: make(map[string]*levelRevert),
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"debug","logger":"api","ttl":"50ms"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// 到期前替换 logger，恢复作用于新的 logger
+	replaced := New(nil)
+	replaced.levels.setLevel("api", ErrorLevel)
+	current.Store(replaced)
+
+	assert.Eventually(t, func() bool {
+		_, ok := replaced.levels.levels()["api"]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,336 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// lowestLevel 是最低的日志级别，底层 Core 使用它放行所有日志，由 levelCore 负责过滤。
+const lowestLevel = zapcore.Level(math.MinInt8)
+
+// maxNamedLoggers registry 最多记录的命名 logger 数量，超过后新的名称不再出现在 levels 的结果中，
+// 避免动态生成的名称使 registry 无限增长。
+const maxNamedLoggers = 1024
+
+// inheritLevel 表示命名 logger 没有单独设置级别或详细级别，跟随全局的设置。
+const inheritLevel = int32(math.MinInt32)
+
+// levelCore 在 Core 之上使用可动态调整的级别过滤日志。
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// withLevel 将 logger 的 levelCore 换成使用 enab 过滤，logger 的 Core 不是 levelCore 时原样返回。
+func withLevel(l *zap.Logger, enab zapcore.LevelEnabler) *zap.Logger {
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return replaceLevel(core, enab)
+	}))
+}
+
+func replaceLevel(core zapcore.Core, enab zapcore.LevelEnabler) zapcore.Core {
+	switch c := core.(type) {
+	case *levelCore:
+		return &levelCore{Core: c.Core, level: enab}
+	case *bufferCore:
+		return &bufferCore{Core: replaceLevel(c.Core, enab), buf: c.buf}
+	}
+
+	return core
+}
+
+// loggerLevel 一个命名 logger 的日志级别和详细级别，未单独设置时跟随全局的设置。
+// 生效的级别在覆盖规则变化后第一次使用时重新计算，registry 不持有 loggerLevel。
+type loggerLevel struct {
+	registry *levelRegistry
+	name     string
+	resolved atomic.Value // *resolvedLevel
+}
+
+// resolvedLevel 按某一版覆盖规则计算出的级别和详细级别。
+type resolvedLevel struct {
+	overrides *levelOverrides
+	level     int32
+	verbosity int32
+}
+
+// resolve 返回按当前覆盖规则计算出的级别和详细级别。
+func (l *loggerLevel) resolve() *resolvedLevel {
+	overrides := l.registry.loadOverrides()
+	if r, ok := l.resolved.Load().(*resolvedLevel); ok && r.overrides == overrides {
+		return r
+	}
+
+	r := &resolvedLevel{overrides: overrides}
+	r.level, r.verbosity = overrides.resolve(l.name)
+	l.resolved.Store(r)
+
+	return r
+}
+
+func (l *loggerLevel) Level() zapcore.Level {
+	if lvl := l.resolve().level; lvl != inheritLevel {
+		return zapcore.Level(lvl)
+	}
+
+	return l.registry.global.Level()
+}
+
+func (l *loggerLevel) Enabled(lvl zapcore.Level) bool {
+	return lvl >= l.Level()
+}
+
+// Verbosity 返回启用的最大详细级别。
+func (l *loggerLevel) Verbosity() int {
+	if v := l.resolve().verbosity; v != inheritLevel {
+		return int(v)
+	}
+
+	return int(atomic.LoadInt32(&l.registry.verbosity))
+}
+
+// levelOverrides 按名称前缀覆盖的日志级别和详细级别。
+type levelOverrides struct {
+	levels    map[string]zapcore.Level
+	verbosity map[string]int
+}
+
+// clone 返回 o 的副本。
+func (o *levelOverrides) clone() *levelOverrides {
+	c := &levelOverrides{
+		levels:    make(map[string]zapcore.Level, len(o.levels)),
+		verbosity: make(map[string]int, len(o.verbosity)),
+	}
+	for name, lvl := range o.levels {
+		c.levels[name] = lvl
+	}
+	for name, v := range o.verbosity {
+		c.verbosity[name] = v
+	}
+
+	return c
+}
+
+// resolve 分别返回 name 最长匹配的前缀上设置的级别和详细级别，没有匹配时返回 inheritLevel。
+// 前缀按 "." 分隔的名称段匹配，如 api.handler 匹配 api.handler.user，但不匹配 api.handlers。
+func (o *levelOverrides) resolve(name string) (level, verbosity int32) {
+	level, verbosity = inheritLevel, inheritLevel
+	for prefix := name; ; {
+		if lvl, ok := o.levels[prefix]; ok && level == inheritLevel {
+			level = int32(lvl)
+		}
+		if v, ok := o.verbosity[prefix]; ok && verbosity == inheritLevel {
+			verbosity = int32(v)
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			return level, verbosity
+		}
+		prefix = prefix[:i]
+	}
+}
+
+// levelRegistry 记录全局日志级别和详细级别、按名称前缀覆盖的设置、vmodule 以及创建过的命名 logger。
+// 覆盖规则是只读的，变化时整体替换，每个命名 logger 生效的级别由最长匹配的前缀决定，
+// 在规则变化后第一次记录日志时重新计算一次。
+//
+// registry 中的名称都是相对于 base (即 Options.Name) 的名称，Name 为 svc 时 WithName("db") 创建的
+// logger 的名称是 svc.db，在 registry 中记为 db。
+type levelRegistry struct {
+	base      string
+	global    zap.AtomicLevel
+	verbosity int32
+	vmodule   atomic.Value // *vmodule
+	overrides atomic.Value // *levelOverrides
+	root      *loggerLevel // New 创建的 logger 的级别，修改它即修改全局级别
+	mu        sync.Mutex   // 串行化覆盖规则的修改，保护 names
+	names     map[string]struct{}
+}
+
+func newLevelRegistry(global zap.AtomicLevel) *levelRegistry {
+	r := &levelRegistry{global: global, names: make(map[string]struct{})}
+	r.overrides.Store(&levelOverrides{
+		levels:    make(map[string]zapcore.Level),
+		verbosity: make(map[string]int),
+	})
+	r.root = r.get("")
+	r.vmodule.Store((*vmodule)(nil))
+
+	return r
+}
+
+// get 返回完整名称 fullName 对应的 loggerLevel，并记录其相对名称，最多记录 maxNamedLoggers 个。
+func (r *levelRegistry) get(fullName string) *loggerLevel {
+	name := r.relative(fullName)
+	if name != "" {
+		r.mu.Lock()
+		if _, ok := r.names[name]; !ok && len(r.names) < maxNamedLoggers {
+			r.names[name] = struct{}{}
+		}
+		r.mu.Unlock()
+	}
+
+	return &loggerLevel{registry: r, name: name}
+}
+
+// relative 返回 logger 的完整名称相对于 base 的名称。
+func (r *levelRegistry) relative(fullName string) string {
+	switch {
+	case r.base == "":
+		return fullName
+	case fullName == r.base:
+		return ""
+	case strings.HasPrefix(fullName, r.base+"."):
+		return fullName[len(r.base)+1:]
+	}
+
+	return fullName
+}
+
+func (r *levelRegistry) loadOverrides() *levelOverrides {
+	return r.overrides.Load().(*levelOverrides)
+}
+
+// setVerbosity 设置全局的详细级别。
+func (r *levelRegistry) setVerbosity(v int) {
+	atomic.StoreInt32(&r.verbosity, int32(v))
+}
+
+// setVModule 设置按源文件覆盖的详细级别。
+func (r *levelRegistry) setVModule(m *vmodule) {
+	r.vmodule.Store(m)
+}
+
+func (r *levelRegistry) getVModule() *vmodule {
+	return r.vmodule.Load().(*vmodule)
+}
+
+func (r *levelRegistry) setLevel(name string, lvl zapcore.Level) (prev zapcore.Level, ok bool) {
+	if name == "" {
+		prev = r.global.Level()
+		r.global.SetLevel(lvl)
+
+		return prev, true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	overrides := r.loadOverrides().clone()
+	prev, ok = overrides.levels[name]
+	overrides.levels[name] = lvl
+	r.overrides.Store(overrides)
+
+	return prev, ok
+}
+
+// resetLevel 删除名称前缀 name 上的设置。
+func (r *levelRegistry) resetLevel(name string) {
+	if name == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	overrides := r.loadOverrides().clone()
+	delete(overrides.levels, name)
+	r.overrides.Store(overrides)
+}
+
+// setOverrides 使用 overrides 替换所有按名称前缀设置的级别和详细级别。
+func (r *levelRegistry) setOverrides(overrides levelOverrides) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.overrides.Store(overrides.clone())
+}
+
+// levels 返回所有创建过的命名 logger 以及设置了级别的名称前缀当前生效的级别。
+func (r *levelRegistry) levels() map[string]zapcore.Level {
+	overrides := r.loadOverrides()
+	global := r.global.Level()
+	levels := make(map[string]zapcore.Level, len(overrides.levels))
+	for name, lvl := range overrides.levels {
+		levels[name] = lvl
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name := range r.names {
+		if _, ok := levels[name]; ok {
+			continue
+		}
+		levels[name] = global
+		if lvl, _ := overrides.resolve(name); lvl != inheritLevel {
+			levels[name] = zapcore.Level(lvl)
+		}
+	}
+
+	return levels
+}
+
+// parseLevelOverrides 解析按名称前缀覆盖的日志级别，如 {"db": "debug", "api.handler": "warn"}。
+// 级别可以是日志级别的名称，也可以是数字 N，表示该 logger 以 Info 级别输出并启用 V(N)。
+func parseLevelOverrides(overrides map[string]string) (levelOverrides, []error) {
+	var errs []error
+	parsed := levelOverrides{
+		levels:    make(map[string]zapcore.Level, len(overrides)),
+		verbosity: make(map[string]int),
+	}
+	// 按名称顺序解析，保证错误的顺序稳定
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		text := overrides[name]
+		if name == "" {
+			errs = append(errs, fmt.Errorf("level override must have a logger name: %q", text))
+			continue
+		}
+		if v, err := strconv.Atoi(text); err == nil {
+			if v < 0 {
+				errs = append(errs, fmt.Errorf("verbosity must not be negative: %s=%q", name, text))
+				continue
+			}
+			parsed.levels[name] = zapcore.InfoLevel
+			parsed.verbosity[name] = v
+			continue
+		}
+		var lvl zapcore.Level
+		if err := lvl.UnmarshalText([]byte(text)); err != nil {
+			errs = append(errs, fmt.Errorf("not a valid level override: %s=%q", name, text))
+			continue
+		}
+		parsed.levels[name] = lvl
+	}
+
+	return parsed, errs
+}
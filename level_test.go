@@ -0,0 +1,175 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_levelCore(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     zapcore.Level
+		entry     zapcore.Level
+		wantCount int
+	}{
+		{
+			name:      "enabled",
+			level:     zapcore.InfoLevel,
+			entry:     zapcore.InfoLevel,
+			wantCount: 1,
+		},
+		{
+			name:      "disabled",
+			level:     zapcore.WarnLevel,
+			entry:     zapcore.InfoLevel,
+			wantCount: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner, logs := observer.New(zapcore.DebugLevel)
+			core := &levelCore{Core: inner, level: zap.NewAtomicLevelAt(tt.level)}
+			l := zap.New(core).With(zap.String("k", "v"))
+
+			assert.Equal(t, tt.wantCount == 1, l.Core().Enabled(tt.entry))
+			l.Check(tt.entry, "msg").Write()
+			assert.Equal(t, tt.wantCount, logs.Len())
+		})
+	}
+}
+
+func Test_levelRegistry(t *testing.T) {
+	global := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	r := newLevelRegistry(global)
+	ll := r.get("api")
+
+	assert.Equal(t, zapcore.InfoLevel, ll.Level())
+	global.SetLevel(zapcore.WarnLevel)
+	assert.Equal(t, zapcore.WarnLevel, ll.Level())
+
+	prev, explicit := r.setLevel("api", zapcore.DebugLevel)
+	assert.False(t, explicit)
+	assert.Equal(t, zapcore.DebugLevel, ll.Level())
+	assert.True(t, ll.Enabled(zapcore.DebugLevel))
+
+	prev, explicit = r.setLevel("api", zapcore.ErrorLevel)
+	assert.True(t, explicit)
+	assert.Equal(t, zapcore.DebugLevel, prev)
+
+	prev, explicit = r.setLevel("", zapcore.InfoLevel)
+	assert.True(t, explicit)
+	assert.Equal(t, zapcore.WarnLevel, prev)
+
+	assert.Equal(t, map[string]zapcore.Level{"api": zapcore.ErrorLevel}, r.levels())
+	r.resetLevel("api")
+	assert.Equal(t, zapcore.InfoLevel, ll.Level())
+}
+
+func Test_zapLogger_WithName_level(t *testing.T) {
+	l := New(nil)
+	api := l.WithName("api")
+	handler := api.WithName("handler").WithValues("key", "value")
+	l.levels.setLevel("api.handler", zapcore.DebugLevel)
+
+	assert.False(t, l.zapLogger.Core().Enabled(zapcore.DebugLevel))
+	assert.False(t, api.(*zapLogger).zapLogger.Core().Enabled(zapcore.DebugLevel))
+	assert.True(t, handler.(*zapLogger).zapLogger.Core().Enabled(zapcore.DebugLevel))
+	assert.Contains(t, l.levels.levels(), "api.handler")
+}
+
+func Test_levelRegistry_resolve(t *testing.T) {
+	r := newLevelRegistry(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+	r.setVerbosity(1)
+	r.setOverrides(levelOverrides{
+		levels: map[string]zapcore.Level{
+			"db":          zapcore.DebugLevel,
+			"api":         zapcore.ErrorLevel,
+			"api.handler": zapcore.WarnLevel,
+		},
+		verbosity: map[string]int{"db": 3, "api.handler.user": 5},
+	})
+	tests := []struct {
+		name          string
+		want          zapcore.Level
+		wantVerbosity int
+	}{
+		{name: "db", want: zapcore.DebugLevel, wantVerbosity: 3},
+		{name: "db.pool", want: zapcore.DebugLevel, wantVerbosity: 3},
+		{name: "dbx", want: zapcore.InfoLevel, wantVerbosity: 1},
+		{name: "api", want: zapcore.ErrorLevel, wantVerbosity: 1},
+		{name: "api.handler.user", want: zapcore.WarnLevel, wantVerbosity: 5},
+		{name: "api.handlers", want: zapcore.ErrorLevel, wantVerbosity: 1},
+		{name: "other", want: zapcore.InfoLevel, wantVerbosity: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.get(tt.name).Level())
+			assert.Equal(t, tt.wantVerbosity, r.get(tt.name).Verbosity())
+		})
+	}
+
+	r.resetLevel("api.handler")
+	assert.Equal(t, zapcore.ErrorLevel, r.get("api.handler.user").Level())
+}
+
+func Test_parseLevelOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      levelOverrides
+		wantErrs  []string
+	}{
+		{
+			name:      "valid",
+			overrides: map[string]string{"db": "debug", "api.handler": "WARN", "worker": "3"},
+			want: levelOverrides{
+				levels: map[string]zapcore.Level{
+					"db":          zapcore.DebugLevel,
+					"api.handler": zapcore.WarnLevel,
+					"worker":      zapcore.InfoLevel,
+				},
+				verbosity: map[string]int{"worker": 3},
+			},
+		},
+		{
+			name:      "invalid",
+			overrides: map[string]string{"db": "verbose", "": "info", "worker": "-1"},
+			want:      levelOverrides{levels: map[string]zapcore.Level{}, verbosity: map[string]int{}},
+			wantErrs: []string{
+				`level override must have a logger name: "info"`,
+				`not a valid level override: db="verbose"`,
+				`verbosity must not be negative: worker="-1"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := parseLevelOverrides(tt.overrides)
+			assert.Equal(t, tt.want, got)
+			var msgs []string
+			for _, err := range errs {
+				msgs = append(msgs, err.Error())
+			}
+			assert.Equal(t, tt.wantErrs, msgs)
+		})
+	}
+}
+
+func TestNew_levelOverrides(t *testing.T) {
+	opts := NewOptions()
+	opts.Level = "error"
+	opts.Name = "svc"
+	opts.LevelOverrides = map[string]string{"db": "debug", "worker": "4"}
+	l := New(opts)
+
+	assert.False(t, l.V(4).Enabled())
+	assert.True(t, l.WithName("worker").V(4).Enabled())
+	assert.False(t, l.WithName("worker").V(5).Enabled())
+	assert.True(t, l.WithName("db").WithName("pool").(*zapLogger).zapLogger.Core().Enabled(zapcore.DebugLevel))
+	assert.False(t, l.WithName("api").(*zapLogger).zapLogger.Core().Enabled(zapcore.InfoLevel))
+	assert.Equal(t, zapcore.DebugLevel, l.levels.levels()["db.pool"])
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sync"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -53,6 +52,24 @@ type Logger interface {
 	Fatalf(format string, v ...interface{})
 	Fatalw(msg string, keysAndValues ...interface{})
 
+	// DebugContext 等方法与对应的方法相同，只在日志级别启用时才从 ctx 中提取字段，
+	// 相比 L(ctx).Debug 省去了每次调用时复制 logger 的开销。
+	DebugContext(ctx context.Context, msg string, fields ...Field)
+	DebugfContext(ctx context.Context, format string, v ...interface{})
+	DebugwContext(ctx context.Context, msg string, keysAndValues ...interface{})
+
+	InfoContext(ctx context.Context, msg string, fields ...Field)
+	InfofContext(ctx context.Context, format string, v ...interface{})
+	InfowContext(ctx context.Context, msg string, keysAndValues ...interface{})
+
+	WarnContext(ctx context.Context, msg string, fields ...Field)
+	WarnfContext(ctx context.Context, format string, v ...interface{})
+	WarnwContext(ctx context.Context, msg string, keysAndValues ...interface{})
+
+	ErrorContext(ctx context.Context, msg string, fields ...Field)
+	ErrorfContext(ctx context.Context, format string, v ...interface{})
+	ErrorwContext(ctx context.Context, msg string, keysAndValues ...interface{})
+
 	// V 返回特定详细级别的 InfoLogger 值。
 	// 一个更高的详细级别意味着日志消息不太重要。
 	// 传递小于零的日志级别是违法的。
@@ -72,6 +89,13 @@ type Logger interface {
 	// WithContext 返回设置日志值的上下文副本。
 	WithContext(ctx context.Context) context.Context
 
+	// SetLevel 动态调整 logger 的日志级别，由它派生的 logger 同样生效。
+	// 命名 logger 调整的是该名称的级别，不影响其他 logger。
+	SetLevel(level Level)
+
+	// GetLevel 返回 logger 当前生效的日志级别。
+	GetLevel() Level
+
 	// Flush 调用底层 Core 的 Sync 方法，刷新所有缓冲的日志条目。
 	// 应用程序应注意在退出前调用 Sync。
 	Flush()
@@ -124,6 +148,14 @@ func (l *infoLogger) Infow(msg string, keysAndValues ...interface{}) {
 // 注意：这看起来与 zap.SugaredLogger 非常相似，但我们希望拥有多个详细级别。
 type zapLogger struct {
 	zapLogger *zap.Logger
+	level     zap.AtomicLevel
+	levels    *levelRegistry
+	name      string
+	closer    func() error
+	reloader  *reloader
+	logLevel  *loggerLevel
+	// ctxFields 表示 logger 已经带有 ContextExtractor 从 ctx 中提取的字段
+	ctxFields bool
 	infoLogger
 }
 
@@ -173,97 +205,150 @@ func handleFields(l *zap.Logger, args []interface{}, additional ...zap.Field) []
 	return append(fields, additional...)
 }
 
-var (
-	std = New(NewOptions())
-	mu  sync.Mutex
-)
+// Init 使用指定的选项初始化全局 logger，与 ReplaceGlobal 相同，返回恢复原来的全局 logger 的函数，
+// 同时恢复 Zap 的全局 logger、标准库 log 包以及 slog 的默认 logger。原来的全局 logger 在替换后会被刷新。
+// 配置无效时 panic，需要处理错误时使用 Options.Build。
+//
+//	restore := log.Init(opts)
+//	defer restore()
+func Init(opts *Options) (restore func()) {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	prev := std()
+	restore = opts.install(New(opts))
+	prev.Flush()
+
+	return restore
+}
+
+// Option 设置 NewWithError 创建的 logger。
+type Option func(c *loggerConfig)
+
+type loggerConfig struct {
+	zapOptions []zap.Option
+	wrapCores  []func(zapcore.Core) zapcore.Core
+}
+
+// WithZapOptions 在创建 logger 时追加 Zap 的选项，如 zap.Hooks、zap.Fields 等。
+func WithZapOptions(opts ...zap.Option) Option {
+	return func(c *loggerConfig) { c.zapOptions = append(c.zapOptions, opts...) }
+}
+
+// WithCoreWrapper 在日志级别的过滤之下包装 logger 的 Core，只有通过级别过滤的日志才会交给 wrap 返回的 Core。
+// 与 zap.WrapCore 不同，包装在重新加载配置后仍然生效，按名称覆盖的级别也对它生效。
+func WithCoreWrapper(wrap func(zapcore.Core) zapcore.Core) Option {
+	return func(c *loggerConfig) { c.wrapCores = append(c.wrapCores, wrap) }
+}
+
+// WithCallerSkip 增加记录调用位置时跳过的调用层数，用于封装了本包 logger 的函数。
+func WithCallerSkip(skip int) Option {
+	return WithZapOptions(zap.AddCallerSkip(skip))
+}
+
+// New 通过 opts 创建 logger，并将标准库 log 包的输出重定向到该 logger。
+// 配置无效时 panic，需要处理错误时使用 NewWithError。
+func New(opts *Options, options ...Option) *zapLogger {
+	l, err := newLogger(opts, options...)
+	if err != nil {
+		panic(err)
+	}
+	redirectStdLog(l)
+
+	return l
+}
+
+// NewWithError 通过 opts 创建 logger，配置无效时返回包含所有错误的 *OptionsError，
+// 无法打开输出等错误同样通过 error 返回。
+// 与 New 不同，NewWithError 没有任何全局的副作用，不会重定向标准库 log 包的输出，需要时使用 Init 或 Options.Build。
+func NewWithError(opts *Options, options ...Option) (Logger, error) {
+	l, err := newLogger(opts, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
 
-// Init 使用指定的选项初始 logger。
-func Init(opts *Options) {
-	mu.Lock()
-	defer mu.Unlock()
-	std = New(opts)
+// redirectStdLog 将标准库 log 包的输出重定向到 l，返回恢复原来的设置的函数。
+func redirectStdLog(l *zapLogger) (restore func()) {
+	return zap.RedirectStdLog(l.direct())
 }
 
-// New 通过 opts 创建 logger。
-func New(opts *Options) *zapLogger {
+func newLogger(opts *Options, options ...Option) (*zapLogger, error) {
 	if opts == nil {
 		opts = NewOptions()
 	}
+	if errs := opts.validate(); len(errs) > 0 {
+		return nil, &OptionsError{Errors: prefixFieldErrors(errs)}
+	}
+	c := &loggerConfig{}
+	for _, opt := range options {
+		opt(c)
+	}
 
 	var zapLevel zapcore.Level
 	if err := zapLevel.UnmarshalText([]byte(opts.Level)); err != nil {
-		zapLevel = zapcore.InfoLevel
-	}
-	encodeLevel := zapcore.CapitalLevelEncoder
-	// 当输出到本地路径时，禁止使用颜色
-	if opts.Format == consoleFormat && opts.EnableColor {
-		encodeLevel = zapcore.CapitalColorLevelEncoder
-	}
-
-	var encodeCaller zapcore.CallerEncoder
-	if opts.EncodeFullCaller {
-		encodeCaller = zapcore.FullCallerEncoder
-	} else {
-		encodeCaller = zapcore.ShortCallerEncoder
-	}
-
-	encoderConfig := zapcore.EncoderConfig{
-		MessageKey:     "message",
-		LevelKey:       "level",
-		TimeKey:        "timestamp",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    encodeLevel,
-		EncodeTime:     timeEncoder,
-		EncodeDuration: milliSecondsDurationEncoder,
-		EncodeCaller:   encodeCaller,
-	}
-
-	loggerConfig := &zap.Config{
-		Level:             zap.NewAtomicLevelAt(zapLevel),
-		Development:       opts.Development,
-		DisableCaller:     opts.DisableCaller,
-		DisableStacktrace: opts.DisableStacktrace,
-		Sampling: &zap.SamplingConfig{
-			Initial:    100,
-			Thereafter: 100,
-		},
-		Encoding:         opts.Format,
-		EncoderConfig:    encoderConfig,
-		OutputPaths:      opts.OutputPaths,
-		ErrorOutputPaths: opts.ErrorOutputPaths,
+		return nil, err
 	}
-
-	l, err := loggerConfig.Build(zap.AddStacktrace(zapcore.PanicLevel), zap.AddCallerSkip(1))
+	overrides, errs := parseLevelOverrides(opts.LevelOverrides)
+	if len(errs) > 0 {
+		return nil, &OptionsError{Errors: errs}
+	}
+	vm, err := parseVModule(opts.VModule)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	level := zap.NewAtomicLevelAt(zapLevel)
+	current := *opts
+	r := &reloader{opts: &current}
+
+	zapOptions := append([]zap.Option{
+		zap.AddStacktrace(zapcore.PanicLevel),
+		zap.AddCallerSkip(1),
+		opts.samplingOption(),
+		zap.WrapCore(r.wrap),
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			for _, wrap := range c.wrapCores {
+				core = wrap(core)
+			}
+			return &levelCore{Core: core, level: level}
+		}),
+	}, c.zapOptions...)
+	l, closer, err := opts.buildConfig(opts.zapConfig(), zapOptions...)
+	if err != nil {
+		return nil, err
 	}
-	logger := &zapLogger{
+	r.setCloser(closer)
+	levels := newLevelRegistry(level)
+	levels.base = opts.Name
+	levels.setOverrides(overrides)
+	levels.setVerbosity(opts.Verbosity)
+	levels.setVModule(vm)
+
+	return &zapLogger{
 		zapLogger: l.Named(opts.Name),
+		level:     level,
+		levels:    levels,
+		name:      opts.Name,
+		closer:    r.close,
+		reloader:  r,
+		logLevel:  levels.root,
 		infoLogger: infoLogger{
 			log:   l,
 			level: zap.InfoLevel,
 		},
-	}
-	zap.RedirectStdLog(l)
-
-	return logger
+	}, nil
 }
 
 // SugaredLogger 返回全局 sugared logger.
 func SugaredLogger() *zap.SugaredLogger {
-	return std.zapLogger.Sugar()
+	return std().zapLogger.Sugar()
 }
 
 // StdErrLogger 返回标准库的 logger，该 logger 在 error level 写入提供的 zap logger。
 func StdErrLogger() *log.Logger {
-	if std == nil {
-		return nil
-	}
-	if l, err := zap.NewStdLogAt(std.zapLogger, zapcore.ErrorLevel); err == nil {
+	if l, err := zap.NewStdLogAt(std().zapLogger, zapcore.ErrorLevel); err == nil {
 		return l
 	}
 
@@ -272,29 +357,18 @@ func StdErrLogger() *log.Logger {
 
 // StdInfoLogger 返回标准库的 logger，该 logger 在 info level 写入提供的 zap logger。
 func StdInfoLogger() *log.Logger {
-	if std == nil {
-		return nil
-	}
-	if l, err := zap.NewStdLogAt(std.zapLogger, zapcore.InfoLevel); err == nil {
+	if l, err := zap.NewStdLogAt(std().zapLogger, zapcore.InfoLevel); err == nil {
 		return l
 	}
 
 	return nil
 }
 
-// V 返回一个分级的 InfoLogger。
-func V(level int) InfoLogger { return std.V(level) }
+// V 返回全局 logger 详细级别为 level 的 InfoLogger。
+func V(level int) InfoLogger { return std().v(level) }
 
 func (l *zapLogger) V(level int) InfoLogger {
-	lvl := zapcore.Level(5 - 1*level)
-	if l.zapLogger.Core().Enabled(lvl) {
-		return &infoLogger{
-			level: lvl,
-			log:   l.zapLogger,
-		}
-	}
-
-	return disabledInfoLogger
+	return l.v(level)
 }
 
 func (l *zapLogger) Write(p []byte) (n int, err error) {
@@ -304,35 +378,96 @@ func (l *zapLogger) Write(p []byte) (n int, err error) {
 }
 
 // WithValues 创建一个 child logger 并向其添加 Zap 字段。
-func WithValues(keysAndValues ...interface{}) Logger { return std.WithValues(keysAndValues...) }
+func WithValues(keysAndValues ...interface{}) Logger { return std().WithValues(keysAndValues...) }
 
 func (l *zapLogger) WithValues(keysAndValues ...interface{}) Logger {
 	newLogger := l.zapLogger.With(handleFields(l.zapLogger, keysAndValues)...)
 
-	return NewLogger(newLogger)
+	return l.child(newLogger)
 }
 
 // WithName 为 logger 的名称添加一个新的路径段。默认情况下，记录器是未命名的。
-func WithName(s string) Logger { return std.WithName(s) }
+func WithName(s string) Logger { return std().WithName(s) }
 
 func (l *zapLogger) WithName(name string) Logger {
 	newLogger := l.zapLogger.Named(name)
+	if l.levels == nil || name == "" {
+		return l.child(newLogger)
+	}
+
+	// 与 zap.Logger.Named 的命名规则保持一致
+	fullName := name
+	if l.name != "" {
+		fullName = l.name + "." + name
+	}
+	logLevel := l.levels.get(fullName)
+	child := l.child(withLevel(newLogger, logLevel))
+	child.name = fullName
+	child.logLevel = logLevel
 
-	return NewLogger(newLogger)
+	return child
+}
+
+// SetLevel 动态调整全局 logger 的日志级别。
+func SetLevel(level Level) { std().SetLevel(level) }
+
+// SetLevel 调整 logger 的日志级别，WithName 派生的 logger 设置的是该名称的级别，
+// 与 Options.LevelOverrides 中的设置相同，对名称以它为前缀的 logger 同样生效。
+func (l *zapLogger) SetLevel(level Level) {
+	if l.levels != nil && l.logLevel != nil && l.logLevel != l.levels.root {
+		l.levels.setLevel(l.logLevel.name, level)
+
+		return
+	}
+	l.level.SetLevel(level)
+}
+
+// GetLevel 返回全局 logger 当前的日志级别。
+func GetLevel() Level { return std().GetLevel() }
+
+// GetLevel 返回 logger 当前生效的日志级别，包括按名称设置的级别。
+func (l *zapLogger) GetLevel() Level {
+	if l.logLevel != nil {
+		return l.logLevel.Level()
+	}
+
+	return l.level.Level()
 }
 
 // Flush 调用底层 Core 的 Sync 方法，刷新所有缓冲的日志条目。
 // 应用程序应注意在退出前调用 Sync。
-func Flush() { std.Flush() }
+func Flush() { std().Flush() }
 
 func (l *zapLogger) Flush() {
 	_ = l.zapLogger.Sync()
 }
 
+// Close 刷新全局 logger 缓冲的日志条目，并停止异步写入的后台 goroutine。
+// 开启异步写入的应用程序应在退出前调用 Close。
+func Close() error { return std().Close() }
+
+// Close 刷新缓冲的日志条目，并停止异步写入的后台 goroutine。
+// 由同一个 New 创建的 logger 共享异步队列和输出，关闭其中一个即可，关闭后输出也随之关闭，不应再记录日志。
+func (l *zapLogger) Close() error {
+	l.Flush()
+	if l.closer == nil {
+		return nil
+	}
+
+	return l.closer()
+}
+
 // NewLogger 使用给定的 Zap Logger 创建一个新的 log.Logger 来记录日志。
+// 返回的 logger 的初始级别与 l 相同，SetLevel 只能在 l 已启用的级别之上进一步过滤。
 func NewLogger(l *zap.Logger) Logger {
+	level := zap.NewAtomicLevelAt(zapcore.LevelOf(l.Core()))
+	l = l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, level: level}
+	}))
+
 	return &zapLogger{
 		zapLogger: l,
+		level:     level,
 		infoLogger: infoLogger{
 			log:   l,
 			level: zap.InfoLevel,
@@ -340,9 +475,27 @@ func NewLogger(l *zap.Logger) Logger {
 	}
 }
 
+// child 使用 l 派生出的 Zap Logger 创建一个共享日志级别的 log.Logger。
+func (l *zapLogger) child(newLogger *zap.Logger) *zapLogger {
+	return &zapLogger{
+		zapLogger: newLogger,
+		level:     l.level,
+		levels:    l.levels,
+		name:      l.name,
+		closer:    l.closer,
+		reloader:  l.reloader,
+		logLevel:  l.logLevel,
+		ctxFields: l.ctxFields,
+		infoLogger: infoLogger{
+			log:   newLogger,
+			level: zap.InfoLevel,
+		},
+	}
+}
+
 // Debug method output debug level log.
 func Debug(msg string, fields ...Field) {
-	std.zapLogger.Debug(msg, fields...)
+	std().zapLogger.Debug(msg, fields...)
 }
 
 func (l *zapLogger) Debug(msg string, fields ...Field) {
@@ -351,7 +504,7 @@ func (l *zapLogger) Debug(msg string, fields ...Field) {
 
 // Debugf method output debug level log.
 func Debugf(format string, v ...interface{}) {
-	std.zapLogger.Sugar().Debugf(format, v...)
+	std().zapLogger.Sugar().Debugf(format, v...)
 }
 
 func (l *zapLogger) Debugf(format string, v ...interface{}) {
@@ -360,7 +513,7 @@ func (l *zapLogger) Debugf(format string, v ...interface{}) {
 
 // Debugw method output debug level log.
 func Debugw(msg string, keysAndValues ...interface{}) {
-	std.zapLogger.Sugar().Debugw(msg, keysAndValues...)
+	std().zapLogger.Sugar().Debugw(msg, keysAndValues...)
 }
 
 func (l *zapLogger) Debugw(msg string, keysAndValues ...interface{}) {
@@ -369,7 +522,7 @@ func (l *zapLogger) Debugw(msg string, keysAndValues ...interface{}) {
 
 // Info method output info level log.
 func Info(msg string, fields ...Field) {
-	std.zapLogger.Info(msg, fields...)
+	std().zapLogger.Info(msg, fields...)
 }
 
 func (l *zapLogger) Info(msg string, fields ...Field) {
@@ -378,7 +531,7 @@ func (l *zapLogger) Info(msg string, fields ...Field) {
 
 // Infof method output info level log.
 func Infof(format string, v ...interface{}) {
-	std.zapLogger.Sugar().Infof(format, v...)
+	std().zapLogger.Sugar().Infof(format, v...)
 }
 
 func (l *zapLogger) Infof(format string, v ...interface{}) {
@@ -387,7 +540,7 @@ func (l *zapLogger) Infof(format string, v ...interface{}) {
 
 // Infow method output info level log.
 func Infow(msg string, keysAndValues ...interface{}) {
-	std.zapLogger.Sugar().Infow(msg, keysAndValues...)
+	std().zapLogger.Sugar().Infow(msg, keysAndValues...)
 }
 
 func (l *zapLogger) Infow(msg string, keysAndValues ...interface{}) {
@@ -396,7 +549,7 @@ func (l *zapLogger) Infow(msg string, keysAndValues ...interface{}) {
 
 // Warn method output warning level log.
 func Warn(msg string, fields ...Field) {
-	std.zapLogger.Warn(msg, fields...)
+	std().zapLogger.Warn(msg, fields...)
 }
 
 func (l *zapLogger) Warn(msg string, fields ...Field) {
@@ -405,7 +558,7 @@ func (l *zapLogger) Warn(msg string, fields ...Field) {
 
 // Warnf method output warning level log.
 func Warnf(format string, v ...interface{}) {
-	std.zapLogger.Sugar().Warnf(format, v...)
+	std().zapLogger.Sugar().Warnf(format, v...)
 }
 
 func (l *zapLogger) Warnf(format string, v ...interface{}) {
@@ -414,7 +567,7 @@ func (l *zapLogger) Warnf(format string, v ...interface{}) {
 
 // Warnw method output warning level log.
 func Warnw(msg string, keysAndValues ...interface{}) {
-	std.zapLogger.Sugar().Warnw(msg, keysAndValues...)
+	std().zapLogger.Sugar().Warnw(msg, keysAndValues...)
 }
 
 func (l *zapLogger) Warnw(msg string, keysAndValues ...interface{}) {
@@ -423,7 +576,7 @@ func (l *zapLogger) Warnw(msg string, keysAndValues ...interface{}) {
 
 // Error method output error level log.
 func Error(msg string, fields ...Field) {
-	std.zapLogger.Error(msg, fields...)
+	std().zapLogger.Error(msg, fields...)
 }
 
 func (l *zapLogger) Error(msg string, fields ...Field) {
@@ -432,7 +585,7 @@ func (l *zapLogger) Error(msg string, fields ...Field) {
 
 // Errorf method output error level log.
 func Errorf(format string, v ...interface{}) {
-	std.zapLogger.Sugar().Errorf(format, v...)
+	std().zapLogger.Sugar().Errorf(format, v...)
 }
 
 func (l *zapLogger) Errorf(format string, v ...interface{}) {
@@ -441,7 +594,7 @@ func (l *zapLogger) Errorf(format string, v ...interface{}) {
 
 // Errorw method output error level log.
 func Errorw(msg string, keysAndValues ...interface{}) {
-	std.zapLogger.Sugar().Errorw(msg, keysAndValues...)
+	std().zapLogger.Sugar().Errorw(msg, keysAndValues...)
 }
 
 func (l *zapLogger) Errorw(msg string, keysAndValues ...interface{}) {
@@ -450,7 +603,7 @@ func (l *zapLogger) Errorw(msg string, keysAndValues ...interface{}) {
 
 // Panic method output panic level log and shutdown application.
 func Panic(msg string, fields ...Field) {
-	std.zapLogger.Panic(msg, fields...)
+	std().zapLogger.Panic(msg, fields...)
 }
 
 func (l *zapLogger) Panic(msg string, fields ...Field) {
@@ -459,7 +612,7 @@ func (l *zapLogger) Panic(msg string, fields ...Field) {
 
 // Panicf method output panic level log and shutdown application.
 func Panicf(format string, v ...interface{}) {
-	std.zapLogger.Sugar().Panicf(format, v...)
+	std().zapLogger.Sugar().Panicf(format, v...)
 }
 
 func (l *zapLogger) Panicf(format string, v ...interface{}) {
@@ -468,7 +621,7 @@ func (l *zapLogger) Panicf(format string, v ...interface{}) {
 
 // Panicw method output panic level log.
 func Panicw(msg string, keysAndValues ...interface{}) {
-	std.zapLogger.Sugar().Panicw(msg, keysAndValues...)
+	std().zapLogger.Sugar().Panicw(msg, keysAndValues...)
 }
 
 func (l *zapLogger) Panicw(msg string, keysAndValues ...interface{}) {
@@ -477,7 +630,7 @@ func (l *zapLogger) Panicw(msg string, keysAndValues ...interface{}) {
 
 // Fatal method output fatal level log.
 func Fatal(msg string, fields ...Field) {
-	std.zapLogger.Fatal(msg, fields...)
+	std().zapLogger.Fatal(msg, fields...)
 }
 
 func (l *zapLogger) Fatal(msg string, fields ...Field) {
@@ -486,7 +639,7 @@ func (l *zapLogger) Fatal(msg string, fields ...Field) {
 
 // Fatalf method output fatal level log.
 func Fatalf(format string, v ...interface{}) {
-	std.zapLogger.Sugar().Fatalf(format, v...)
+	std().zapLogger.Sugar().Fatalf(format, v...)
 }
 
 func (l *zapLogger) Fatalf(format string, v ...interface{}) {
@@ -495,32 +648,287 @@ func (l *zapLogger) Fatalf(format string, v ...interface{}) {
 
 // Fatalw method output Fatalw level log.
 func Fatalw(msg string, keysAndValues ...interface{}) {
-	std.zapLogger.Sugar().Fatalw(msg, keysAndValues...)
+	std().zapLogger.Sugar().Fatalw(msg, keysAndValues...)
 }
 
 func (l *zapLogger) Fatalw(msg string, keysAndValues ...interface{}) {
 	l.zapLogger.Sugar().Fatalw(msg, keysAndValues...)
 }
 
+// DebugContext method output debug level log with fields extracted from ctx.
+func DebugContext(ctx context.Context, msg string, fields ...Field) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+func (l *zapLogger) DebugContext(ctx context.Context, msg string, fields ...Field) {
+	if !l.zapLogger.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+// DebugfContext method output debug level log with fields extracted from ctx.
+func DebugfContext(ctx context.Context, format string, v ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.DebugLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+func (l *zapLogger) DebugfContext(ctx context.Context, format string, v ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.DebugLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+// DebugwContext method output debug level log with fields extracted from ctx.
+func DebugwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
+func (l *zapLogger) DebugwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
+// InfoContext method output info level log with fields extracted from ctx.
+func InfoContext(ctx context.Context, msg string, fields ...Field) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.InfoLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+func (l *zapLogger) InfoContext(ctx context.Context, msg string, fields ...Field) {
+	if !l.zapLogger.Core().Enabled(zapcore.InfoLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+// InfofContext method output info level log with fields extracted from ctx.
+func InfofContext(ctx context.Context, format string, v ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.InfoLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.InfoLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+func (l *zapLogger) InfofContext(ctx context.Context, format string, v ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.InfoLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.InfoLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+// InfowContext method output info level log with fields extracted from ctx.
+func InfowContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.InfoLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
+func (l *zapLogger) InfowContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.InfoLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
+// WarnContext method output warning level log with fields extracted from ctx.
+func WarnContext(ctx context.Context, msg string, fields ...Field) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.WarnLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+func (l *zapLogger) WarnContext(ctx context.Context, msg string, fields ...Field) {
+	if !l.zapLogger.Core().Enabled(zapcore.WarnLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+// WarnfContext method output warning level log with fields extracted from ctx.
+func WarnfContext(ctx context.Context, format string, v ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.WarnLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.WarnLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+func (l *zapLogger) WarnfContext(ctx context.Context, format string, v ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.WarnLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.WarnLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+// WarnwContext method output warning level log with fields extracted from ctx.
+func WarnwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.WarnLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
+func (l *zapLogger) WarnwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.WarnLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
+// ErrorContext method output error level log with fields extracted from ctx.
+func ErrorContext(ctx context.Context, msg string, fields ...Field) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.ErrorLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+func (l *zapLogger) ErrorContext(ctx context.Context, msg string, fields ...Field) {
+	if !l.zapLogger.Core().Enabled(zapcore.ErrorLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, fields)...)
+	}
+}
+
+// ErrorfContext method output error level log with fields extracted from ctx.
+func ErrorfContext(ctx context.Context, format string, v ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.ErrorLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.ErrorLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+func (l *zapLogger) ErrorfContext(ctx context.Context, format string, v ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.ErrorLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.ErrorLevel, fmt.Sprintf(format, v...)); ce != nil {
+		ce.Write(l.withCallerFields(ctx, nil)...)
+	}
+}
+
+// ErrorwContext method output error level log with fields extracted from ctx.
+func ErrorwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := std()
+	if !l.zapLogger.Core().Enabled(zapcore.ErrorLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
+func (l *zapLogger) ErrorwContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.zapLogger.Core().Enabled(zapcore.ErrorLevel) {
+		return
+	}
+	if ce := l.zapLogger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(l.withCallerFields(ctx, handleFields(l.zapLogger, keysAndValues))...)
+	}
+}
+
 // L method output with specified context value.
 func L(ctx context.Context) *zapLogger {
-	return std.L(ctx)
+	return std().L(ctx)
 }
 
 func (l *zapLogger) L(ctx context.Context) *zapLogger {
 	lg := l.clone()
-
-	if requestID := ctx.Value(KeyRequestID); requestID != nil {
-		lg.zapLogger = lg.zapLogger.With(zap.Any("request-id", requestID))
+	if l.ctxFields {
+		return lg
 	}
 
-	if eID := ctx.Value(KeyEID); eID != nil {
-		lg.zapLogger = lg.zapLogger.With(zap.Any("eid", eID))
+	if fields := contextFields(ctx); len(fields) > 0 {
+		lg.zapLogger = lg.zapLogger.With(fields...)
+		lg.ctxFields = true
 	}
 
 	return lg
 }
 
+// withCallerFields 返回 ctx 中提取的字段与调用方传入的 fields 合并后的结果，
+// logger 已经带有 FromContext 或 L 添加的字段时不再重复提取。
+func (l *zapLogger) withCallerFields(ctx context.Context, fields []Field) []Field {
+	if l.ctxFields {
+		return fields
+	}
+
+	return withCallerFields(ctx, fields)
+}
+
+// direct 返回直接调用时使用的 Zap Logger，去掉本包的函数和方法额外跳过的一层调用。
+func (l *zapLogger) direct() *zap.Logger {
+	return l.zapLogger.WithOptions(zap.AddCallerSkip(-1))
+}
+
 func (l *zapLogger) clone() *zapLogger {
 	copyLogger := *l
 
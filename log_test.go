@@ -3,11 +3,17 @@ package log
 import (
 	"context"
 	"fmt"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestDebug(t *testing.T) {
@@ -1958,3 +1964,326 @@ func Test_zapLogger_clone(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level Level
+	}{
+		{
+			name:  "debug",
+			level: DebugLevel,
+		},
+		{
+			name:  "info",
+			level: InfoLevel,
+		},
+	}
+	defer SetLevel(GetLevel())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLevel(tt.level)
+			assert.Equal(t, tt.level, GetLevel())
+		})
+	}
+}
+
+func Test_zapLogger_SetLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level Level
+	}{
+		{
+			name:  "debug",
+			level: DebugLevel,
+		},
+		{
+			name:  "error",
+			level: ErrorLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(nil)
+			named := l.WithName("child")
+			withValues := l.WithValues("key", "value")
+			fromContext := l.L(context.Background())
+
+			l.SetLevel(tt.level)
+			assert.Equal(t, tt.level, l.GetLevel())
+			for _, child := range []Logger{named, withValues, fromContext} {
+				assert.Equal(t, tt.level, child.GetLevel())
+				assert.True(t, child.(*zapLogger).zapLogger.Core().Enabled(tt.level))
+				assert.False(t, child.(*zapLogger).zapLogger.Core().Enabled(tt.level-1))
+			}
+		})
+	}
+}
+
+func Test_zapLogger_SetLevel_named(t *testing.T) {
+	opts := NewOptions()
+	opts.Name = "app"
+	opts.LevelOverrides = map[string]string{"db": "debug"}
+	l := New(opts)
+	db := l.WithName("db")
+	pool := db.WithName("pool")
+	api := l.WithName("api")
+
+	assert.Equal(t, InfoLevel, l.GetLevel())
+	assert.Equal(t, DebugLevel, db.GetLevel())
+	assert.Equal(t, DebugLevel, pool.GetLevel())
+
+	db.SetLevel(ErrorLevel)
+	assert.Equal(t, ErrorLevel, db.GetLevel())
+	assert.Equal(t, ErrorLevel, pool.WithValues("key", "value").GetLevel())
+	assert.False(t, db.(*zapLogger).zapLogger.Core().Enabled(WarnLevel))
+	assert.Equal(t, InfoLevel, l.GetLevel())
+	assert.Equal(t, InfoLevel, api.GetLevel())
+
+	l.SetLevel(WarnLevel)
+	assert.Equal(t, WarnLevel, api.GetLevel())
+	assert.Equal(t, ErrorLevel, db.GetLevel())
+}
+
+func TestNewLogger_SetLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := NewLogger(zap.New(core))
+	assert.Equal(t, InfoLevel, l.GetLevel())
+
+	l.SetLevel(WarnLevel)
+	l.Info("info")
+	l.WithName("child").Warn("warn")
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "warn", logs.All()[0].Message)
+}
+
+func Test_zapLogger_Context(t *testing.T) {
+	ctx := context.WithValue(context.Background(), KeyRequestID, "r1")
+	tests := []struct {
+		name  string
+		log   func(l Logger)
+		level zapcore.Level
+		msg   string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "debug",
+			log:   func(l Logger) { l.DebugContext(ctx, "debug", String("key", "value")) },
+			level: zapcore.DebugLevel,
+			msg:   "debug",
+			want:  map[string]interface{}{"request-id": "r1", "key": "value"},
+		},
+		{
+			name:  "infof",
+			log:   func(l Logger) { l.InfofContext(ctx, "info %d", 1) },
+			level: zapcore.InfoLevel,
+			msg:   "info 1",
+			want:  map[string]interface{}{"request-id": "r1"},
+		},
+		{
+			name:  "warnw",
+			log:   func(l Logger) { l.WarnwContext(ctx, "warn", "key", "value") },
+			level: zapcore.WarnLevel,
+			msg:   "warn",
+			want:  map[string]interface{}{"request-id": "r1", "key": "value"},
+		},
+		{
+			name:  "error without context fields",
+			log:   func(l Logger) { l.ErrorContext(context.Background(), "error") },
+			level: zapcore.ErrorLevel,
+			msg:   "error",
+			want:  map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			tt.log(NewLogger(zap.New(core)))
+
+			if assert.Equal(t, 1, logs.Len()) {
+				entry := logs.All()[0]
+				assert.Equal(t, tt.level, entry.Level)
+				assert.Equal(t, tt.msg, entry.Message)
+				assert.Equal(t, tt.want, entry.ContextMap())
+			}
+		})
+	}
+}
+
+func Test_zapLogger_Context_disabled(t *testing.T) {
+	extractorsMu.RLock()
+	saved := append([]ContextExtractor(nil), extractors...)
+	extractorsMu.RUnlock()
+	defer func() {
+		extractorsMu.Lock()
+		extractors = saved
+		extractorsMu.Unlock()
+	}()
+
+	calls := 0
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		calls++
+		return nil
+	})
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	l := NewLogger(zap.New(core))
+	l.DebugContext(context.Background(), "debug")
+	l.InfofContext(context.Background(), "info %d", 1)
+	l.InfowContext(context.Background(), "info", "key", "value")
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, 0, logs.Len())
+
+	l.WarnContext(context.Background(), "warn")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestInfoContext_caller(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	opts := NewOptions()
+	opts.Format = jsonFormat
+	opts.OutputPaths = []string{file}
+	l := New(opts)
+
+	l.InfoContext(context.Background(), "info")
+	l.InfofContext(context.Background(), "info %d", 1)
+	l.InfowContext(context.Background(), "info", "key", "value")
+	l.Flush()
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.Contains(t, line, `/log_test.go:`)
+	}
+	assert.NoError(t, l.Close())
+}
+
+func TestNewWithError(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(o *Options)
+		wantErr bool
+	}{
+		{name: "default"},
+		{name: "format", modify: func(o *Options) { o.Format = "text" }, wantErr: true},
+		{name: "output path", modify: func(o *Options) { o.OutputPaths = []string{"/nonexistent/dir/app.log"} }, wantErr: true},
+		{name: "async output path", modify: func(o *Options) {
+			o.Async = true
+			o.OutputPaths = []string{"/nonexistent/dir/app.log"}
+		}, wantErr: true},
+		{name: "redact pattern", modify: func(o *Options) { o.RedactValuePatterns = []string{"("} }, wantErr: true},
+		{name: "level", modify: func(o *Options) { o.Level = "verbose" }, wantErr: true},
+		{name: "level overrides", modify: func(o *Options) { o.LevelOverrides = map[string]string{"db": "loud"} }, wantErr: true},
+		{name: "vmodule", modify: func(o *Options) { o.VModule = "gc=x" }, wantErr: true},
+		{name: "sampling exempt level", modify: func(o *Options) { o.SamplingExemptLevel = "never" }, wantErr: true},
+		{name: "async overflow", modify: func(o *Options) { o.AsyncOverflow = "drop-all" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			if tt.modify != nil {
+				tt.modify(o)
+			}
+			l, err := NewWithError(o)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, l)
+				if len(o.Validate()) > 0 {
+					var optsErr *OptionsError
+					assert.ErrorAs(t, err, &optsErr)
+				}
+				assert.Panics(t, func() { New(o) })
+
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, l)
+		})
+	}
+}
+
+func TestNew_redirectStdLog(t *testing.T) {
+	flags, prefix, out := stdlog.Flags(), stdlog.Prefix(), stdlog.Writer()
+	defer func() {
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+		stdlog.SetOutput(out)
+	}()
+
+	filename := filepath.Join(t.TempDir(), "app.log")
+	opts := NewOptions()
+	opts.OutputPaths = []string{filename}
+	l := New(opts)
+	stdlog.Print("from stdlib")
+	assert.NoError(t, l.Close())
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "from stdlib")
+	assert.Contains(t, string(data), "log_test.go:", "caller must be the stdlib log call site")
+}
+
+func TestNewWithError_stdLog(t *testing.T) {
+	out := stdlog.Writer()
+
+	l, err := NewWithError(NewOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, out, stdlog.Writer(), "NewWithError must not redirect the stdlib log")
+	assert.NoError(t, l.(*zapLogger).Close())
+}
+
+func TestNewWithError_options(t *testing.T) {
+	var entries []zapcore.Entry
+	opts := NewOptions()
+	opts.OutputPaths = []string{filepath.Join(t.TempDir(), "app.log")}
+	l, err := NewWithError(opts,
+		WithZapOptions(zap.Hooks(func(e zapcore.Entry) error {
+			entries = append(entries, e)
+			return nil
+		})),
+		WithCallerSkip(1),
+	)
+	assert.NoError(t, err)
+
+	_, _, line, _ := runtime.Caller(0)
+	logWrapped(l, "wrapped")
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, line+1, entries[0].Caller.Line)
+	}
+}
+
+func logWrapped(l Logger, msg string) {
+	l.Info(msg)
+}
+
+func TestWithCoreWrapper(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	dir := t.TempDir()
+	opts := NewOptions()
+	opts.OutputPaths = []string{filepath.Join(dir, "first.log")}
+	opts.LevelOverrides = map[string]string{"db": "debug"}
+	l := New(opts, WithCoreWrapper(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, core)
+	}))
+	defer l.Close()
+
+	l.Debug("dropped")
+	l.WithName("db").Debug("query")
+
+	next := NewOptions()
+	next.OutputPaths = []string{filepath.Join(dir, "second.log")}
+	next.LevelOverrides = opts.LevelOverrides
+	assert.NoError(t, l.reload(next))
+	l.WithValues("k", "v").Info("reloaded")
+
+	got := logs.AllUntimed()
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "query", got[0].Message)
+		assert.Equal(t, "db", got[0].LoggerName)
+		assert.Equal(t, "reloaded", got[1].Message)
+		assert.Equal(t, map[string]interface{}{"k": "v"}, got[1].ContextMap())
+	}
+}
@@ -0,0 +1,237 @@
+// Package logtest 提供在测试中使用的 log.Logger，记录的日志保存在内存中，方便断言。
+package logtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/eachinchung/log"
+)
+
+// UpdateEnv 设置为非空值时，AssertGolden 使用记录的日志覆盖 golden 文件。
+const UpdateEnv = "LOGTEST_UPDATE"
+
+// Entry 一条记录的日志。
+type Entry struct {
+	Level   log.Level              `json:"level"`
+	Name    string                 `json:"logger,omitempty"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Caller  zapcore.EntryCaller    `json:"-"`
+	Time    time.Time              `json:"-"`
+}
+
+// Recorder 保存 logger 记录的日志。
+type Recorder struct {
+	t    testing.TB
+	logs *observer.ObservedLogs
+}
+
+// New 返回一个将日志保存在内存中的 Logger，以及用于读取和断言这些日志的 Recorder。
+// Logger 的初始级别为 Debug，可以使用 SetLevel 调整。
+func New(t testing.TB) (log.Logger, *Recorder) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := log.NewLogger(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)))
+
+	return logger, &Recorder{t: t, logs: logs}
+}
+
+func newEntry(e observer.LoggedEntry) Entry {
+	return Entry{
+		Level:   e.Level,
+		Name:    e.LoggerName,
+		Message: e.Message,
+		Fields:  e.ContextMap(),
+		Caller:  e.Caller,
+		Time:    e.Time,
+	}
+}
+
+func newEntries(logs []observer.LoggedEntry) []Entry {
+	entries := make([]Entry, 0, len(logs))
+	for _, e := range logs {
+		entries = append(entries, newEntry(e))
+	}
+
+	return entries
+}
+
+// Len 返回记录的日志条数。
+func (r *Recorder) Len() int {
+	return r.logs.Len()
+}
+
+// All 按记录的顺序返回所有日志。
+func (r *Recorder) All() []Entry {
+	return newEntries(r.logs.All())
+}
+
+// TakeAll 按记录的顺序返回所有日志，并清空 Recorder。
+func (r *Recorder) TakeAll() []Entry {
+	return newEntries(r.logs.TakeAll())
+}
+
+// FilterByName 返回名称为 name 的 logger 记录的日志。
+func (r *Recorder) FilterByName(name string) []Entry {
+	return r.filter(func(e Entry) bool { return e.Name == name })
+}
+
+// FilterByLevel 返回级别为 level 的日志。
+func (r *Recorder) FilterByLevel(level log.Level) []Entry {
+	return r.filter(func(e Entry) bool { return e.Level == level })
+}
+
+// FilterByMessage 返回消息中包含 substr 的日志。
+func (r *Recorder) FilterByMessage(substr string) []Entry {
+	return r.filter(func(e Entry) bool { return strings.Contains(e.Message, substr) })
+}
+
+func (r *Recorder) filter(match func(e Entry) bool) []Entry {
+	var entries []Entry
+	for _, e := range r.All() {
+		if match(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// Logged 返回是否有一条级别为 level、消息中包含 msgSubstring 并且带有 fields 中所有字段的日志。
+func (r *Recorder) Logged(level log.Level, msgSubstring string, fields ...log.Field) bool {
+	want := fieldMap(fields)
+	for _, e := range r.All() {
+		if e.Level == level && strings.Contains(e.Message, msgSubstring) && containsFields(e.Fields, want) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AssertLogged 断言有一条级别为 level、消息中包含 msgSubstring 并且带有 fields 中所有字段的日志。
+func (r *Recorder) AssertLogged(level log.Level, msgSubstring string, fields ...log.Field) bool {
+	r.t.Helper()
+
+	if r.Logged(level, msgSubstring, fields...) {
+		return true
+	}
+	r.t.Errorf("no %s entry contains message %q with fields %v, recorded entries:\n%s",
+		level, msgSubstring, fieldMap(fields), r.dump())
+
+	return false
+}
+
+// AssertNotLogged 断言没有级别为 level、消息中包含 msgSubstring 并且带有 fields 中所有字段的日志。
+func (r *Recorder) AssertNotLogged(level log.Level, msgSubstring string, fields ...log.Field) bool {
+	r.t.Helper()
+
+	if !r.Logged(level, msgSubstring, fields...) {
+		return true
+	}
+	r.t.Errorf("unexpected %s entry contains message %q with fields %v, recorded entries:\n%s",
+		level, msgSubstring, fieldMap(fields), r.dump())
+
+	return false
+}
+
+// JSON 返回所有日志的 JSON 快照，不包含时间和调用位置，便于与 golden 文件比较。
+func (r *Recorder) JSON() []byte {
+	data, err := json.MarshalIndent(r.All(), "", "  ")
+	if err != nil {
+		r.t.Fatalf("marshal entries: %v", err)
+	}
+
+	return append(data, '\n')
+}
+
+// AssertGolden 断言所有日志的 JSON 快照与 golden 文件 testdata/<name>.golden.json 相同。
+// 环境变量 LOGTEST_UPDATE 不为空时，使用当前的快照覆盖 golden 文件。
+func (r *Recorder) AssertGolden(name string) bool {
+	r.t.Helper()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	got := r.JSON()
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			r.t.Fatalf("update golden file: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			r.t.Fatalf("update golden file: %v", err)
+		}
+
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		r.t.Errorf("read golden file: %v (set %s=1 to create it)", err, UpdateEnv)
+
+		return false
+	}
+	if !bytes.Equal(want, got) {
+		r.t.Errorf("log entries do not match golden file %s (set %s=1 to update it)\nwant:\n%s\ngot:\n%s",
+			path, UpdateEnv, want, got)
+
+		return false
+	}
+
+	return true
+}
+
+func (r *Recorder) dump() string {
+	var b strings.Builder
+	for _, e := range r.All() {
+		b.WriteString("  ")
+		b.WriteString(e.Level.CapitalString())
+		if e.Name != "" {
+			b.WriteString(" " + e.Name)
+		}
+		b.WriteString(" " + e.Message)
+		if len(e.Fields) > 0 {
+			data, _ := json.Marshal(e.Fields)
+			b.WriteString(" " + string(data))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// fieldMap 将字段编码为与 Entry.Fields 相同的形式。
+func fieldMap(fields []log.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	return enc.Fields
+}
+
+func containsFields(got, want map[string]interface{}) bool {
+	for key, value := range want {
+		v, ok := got[key]
+		if !ok || !jsonEqual(v, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jsonEqual 按 JSON 编码比较两个值，避免 int 与 int64 等类型差异。
+func jsonEqual(a, b interface{}) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
@@ -0,0 +1,101 @@
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eachinchung/log"
+)
+
+func TestNew(t *testing.T) {
+	logger, rec := New(t)
+	logger.Debug("debug", log.String("key", "value"))
+	logger.WithName("api").Infow("request", "status", 200)
+	logger.WithValues("user", "alice").Error("failed", log.Int("attempt", 3))
+
+	entries := rec.All()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, Entry{
+			Level:   log.DebugLevel,
+			Message: "debug",
+			Fields:  map[string]interface{}{"key": "value"},
+			Caller:  entries[0].Caller,
+			Time:    entries[0].Time,
+		}, entries[0])
+		assert.Equal(t, "api", entries[1].Name)
+		assert.True(t, strings.HasSuffix(entries[0].Caller.File, "logtest_test.go"), entries[0].Caller.File)
+	}
+
+	rec.AssertLogged(log.InfoLevel, "req", log.Int("status", 200))
+	rec.AssertLogged(log.ErrorLevel, "failed", log.String("user", "alice"), log.Int64("attempt", 3))
+	rec.AssertNotLogged(log.InfoLevel, "failed")
+	assert.False(t, rec.Logged(log.InfoLevel, "request", log.Int("status", 500)))
+}
+
+func TestRecorder_filter(t *testing.T) {
+	logger, rec := New(t)
+	logger.WithName("db").Info("query")
+	logger.WithName("api").Warn("slow request")
+	logger.WithName("db").Warn("slow query")
+
+	assert.Len(t, rec.FilterByName("db"), 2)
+	assert.Len(t, rec.FilterByLevel(log.WarnLevel), 2)
+	assert.Len(t, rec.FilterByMessage("slow"), 2)
+	assert.Empty(t, rec.FilterByName("cache"))
+
+	assert.Len(t, rec.TakeAll(), 3)
+	assert.Equal(t, 0, rec.Len())
+}
+
+// fakeTB 记录断言失败和输出的日志，而不是让测试失败。
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	logs     []string
+	failed   bool
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Logf(format string, args ...interface{}) {
+	f.logs = append(f.logs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fail() { f.failed = true }
+
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+
+// finish 模拟测试结束。
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestRecorder_AssertLogged_failure(t *testing.T) {
+	tb := &fakeTB{}
+	logger, rec := New(tb)
+	logger.Info("recorded", log.String("key", "value"))
+
+	assert.False(t, rec.AssertLogged(log.InfoLevel, "missing"))
+	assert.False(t, rec.AssertNotLogged(log.InfoLevel, "recorded"))
+	if assert.Len(t, tb.errors, 2) {
+		assert.Contains(t, tb.errors[0], `INFO recorded {"key":"value"}`)
+	}
+}
+
+func TestRecorder_AssertGolden(t *testing.T) {
+	logger, rec := New(t)
+	logger.WithName("api").Infow("request", "method", "GET", "status", 200)
+	logger.Warn("slow", log.Duration("elapsed", 0))
+
+	rec.AssertGolden("snapshot")
+}
@@ -0,0 +1,113 @@
+package logtest
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/eachinchung/log"
+)
+
+// Option 设置 NewT 创建的 Logger。
+type Option func(c *tConfig)
+
+type tConfig struct {
+	options     *log.Options
+	failOnError bool
+}
+
+// WithOptions 使用 opts 中的 Level、Format、EnableColor、EncodeFullCaller 和 DisableCaller 设置日志的级别和格式。
+func WithOptions(opts *log.Options) Option {
+	return func(c *tConfig) { c.options = opts }
+}
+
+// FailOnError 设置记录 Error 及以上级别的日志时将测试标记为失败。
+func FailOnError() Option {
+	return func(c *tConfig) { c.failOnError = true }
+}
+
+// NewT 返回一个通过 t.Logf 输出日志的 Logger，日志只在测试失败或使用 -v 运行时显示，
+// 并与测试的输出交错排列。默认使用不带颜色的 console 格式，级别为 Debug。
+// 测试结束后记录的日志会被丢弃，不会导致 panic。
+func NewT(t testing.TB, opts ...Option) log.Logger {
+	options := log.NewOptions()
+	options.Level = log.DebugLevel.String()
+	options.EnableColor = false
+	c := &tConfig{options: options}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	enc, err := c.options.Encoder()
+	if err != nil {
+		t.Fatalf("logtest: %v", err)
+	}
+	level := log.DebugLevel
+	if err := level.UnmarshalText([]byte(c.options.Level)); err != nil {
+		t.Fatalf("logtest: %v", err)
+	}
+
+	w := &testWriter{t: t}
+	t.Cleanup(w.close)
+
+	zapOpts := []zap.Option{zap.ErrorOutput(w)}
+	if !c.options.DisableCaller {
+		zapOpts = append(zapOpts, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+	if c.failOnError {
+		zapOpts = append(zapOpts, zap.Hooks(func(e zapcore.Entry) error {
+			if e.Level >= zapcore.ErrorLevel {
+				w.fail()
+			}
+
+			return nil
+		}))
+	}
+
+	logger := log.NewLogger(zap.New(zapcore.NewCore(enc, w, zapcore.DebugLevel), zapOpts...))
+	logger.SetLevel(level)
+
+	return logger
+}
+
+// testWriter 将日志写入 testing.TB，测试结束后丢弃日志。
+type testWriter struct {
+	mu   sync.Mutex
+	t    testing.TB
+	done bool
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.done {
+		// t.Logf 会自动添加换行
+		w.t.Logf("%s", bytes.TrimRight(p, "\n"))
+	}
+
+	return len(p), nil
+}
+
+func (w *testWriter) Sync() error {
+	return nil
+}
+
+func (w *testWriter) fail() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.done {
+		w.t.Fail()
+	}
+}
+
+func (w *testWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.done = true
+}
@@ -0,0 +1,100 @@
+package logtest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eachinchung/log"
+)
+
+func TestNewT(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		log      func(l log.Logger)
+		wantLogs int
+		wantFail bool
+	}{
+		{
+			name: "default",
+			log: func(l log.Logger) {
+				l.Debug("debug")
+				l.Error("error")
+			},
+			wantLogs: 2,
+		},
+		{
+			name: "fail on error",
+			opts: []Option{FailOnError()},
+			log: func(l log.Logger) {
+				l.Warn("warn")
+				l.Error("error")
+			},
+			wantLogs: 2,
+			wantFail: true,
+		},
+		{
+			name: "level",
+			opts: []Option{WithOptions(&log.Options{Level: "warn", Format: "console"})},
+			log: func(l log.Logger) {
+				l.Info("info")
+				l.Warn("warn")
+			},
+			wantLogs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			tt.log(NewT(tb, tt.opts...))
+
+			assert.Len(t, tb.logs, tt.wantLogs)
+			assert.Equal(t, tt.wantFail, tb.failed)
+			for _, line := range tb.logs {
+				assert.NotContains(t, line, "\n")
+				assert.NotContains(t, line, "\x1b[")
+			}
+		})
+	}
+}
+
+func TestNewT_format(t *testing.T) {
+	tb := &fakeTB{}
+	opts := log.NewOptions()
+	opts.Format = "json"
+	NewT(tb, WithOptions(opts)).Infow("hello", "key", "value")
+
+	if assert.Len(t, tb.logs, 1) {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(tb.logs[0]), &entry))
+		assert.Equal(t, "hello", entry["message"])
+		assert.Equal(t, "value", entry["key"])
+		assert.Contains(t, entry["caller"], "logtest/t_test.go")
+	}
+
+	tb = &fakeTB{}
+	opts = log.NewOptions()
+	opts.EnableColor = true
+	NewT(tb, WithOptions(opts)).Info("hello")
+	if assert.Len(t, tb.logs, 1) {
+		assert.Contains(t, tb.logs[0], "\x1b[")
+	}
+}
+
+func TestNewT_afterTest(t *testing.T) {
+	tb := &fakeTB{}
+	l := NewT(tb, FailOnError())
+	l.Info("during")
+	tb.finish()
+
+	assert.NotPanics(t, func() { l.Error("after") })
+	assert.Len(t, tb.logs, 1)
+	assert.False(t, tb.failed)
+}
+
+func TestNewT_real(t *testing.T) {
+	l := NewT(t)
+	l.WithName("real").Infow("routed through t.Logf", "key", "value")
+}
@@ -0,0 +1,268 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// HeaderRequestID 传递请求 ID 的 HTTP 头。
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength 请求中携带的请求 ID 的最大长度，超过或包含不可打印字符时重新生成。
+const maxRequestIDLength = 128
+
+// HTTPMiddlewareOption 设置 HTTPMiddleware。
+type HTTPMiddlewareOption func(m *httpMiddleware)
+
+// HTTPStatusLevel 设置按响应状态码选择访问日志级别的函数，默认 5xx 为 Error，4xx 为 Warn，其余为 Info。
+// 返回值低于 logger 的日志级别时不记录访问日志。
+func HTTPStatusLevel(level func(status int) Level) HTTPMiddlewareOption {
+	return func(m *httpMiddleware) {
+		if level != nil {
+			m.level = level
+		}
+	}
+}
+
+// HTTPSkipPaths 设置不记录访问日志的请求路径，路径需要完全匹配，这些请求仍然会设置请求 ID 和 logger。
+func HTTPSkipPaths(paths ...string) HTTPMiddlewareOption {
+	return func(m *httpMiddleware) {
+		for _, path := range paths {
+			m.skip[path] = struct{}{}
+		}
+	}
+}
+
+// HTTPCaptureHeaders 设置记录到访问日志 headers 字段中的请求头，字段名为小写的请求头名称。
+// headers 字段同样经过脱敏，可以使用 RedactKeys 隐藏 authorization 等敏感的请求头。
+func HTTPCaptureHeaders(names ...string) HTTPMiddlewareOption {
+	return func(m *httpMiddleware) {
+		for _, name := range names {
+			m.headers = append(m.headers, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+type httpMiddleware struct {
+	logger  Logger
+	level   func(status int) Level
+	skip    map[string]struct{}
+	headers []string
+}
+
+// HTTPMiddleware 返回为每个请求设置请求 ID 和 logger，并在请求结束后记录访问日志的 net/http 中间件。
+//
+// 请求头中的 X-Request-ID 作为请求 ID，没有时随机生成，请求 ID 写入响应头，并以 KeyRequestID 保存在请求的上下文中。
+// logger 通过 WithContext 保存在请求的上下文中，处理函数可以使用 FromContext(r.Context()) 获取带有请求 ID 的 logger。
+//
+// 访问日志记录 method、path、status、bytes、latency、remote-ip 和 user-agent 字段，
+// latency 由 milliSecondsDurationEncoder 编码为毫秒。处理函数 panic 时状态码记为 500，记录后继续 panic。
+//
+//	handler = log.HTTPMiddleware(logger, log.HTTPSkipPaths("/healthz"))(handler)
+func HTTPMiddleware(logger Logger, opts ...HTTPMiddlewareOption) func(http.Handler) http.Handler {
+	m := &httpMiddleware{
+		logger: logger,
+		level:  defaultStatusLevel,
+		skip:   make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serve(next, w, r)
+		})
+	}
+}
+
+// defaultStatusLevel 5xx 为 Error，4xx 为 Warn，其余为 Info。
+func defaultStatusLevel(status int) Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return ErrorLevel
+	case status >= http.StatusBadRequest:
+		return WarnLevel
+	}
+
+	return InfoLevel
+}
+
+func (m *httpMiddleware) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	id := r.Header.Get(HeaderRequestID)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	w.Header().Set(HeaderRequestID, id)
+
+	ctx := context.WithValue(r.Context(), KeyRequestID, id)
+	ctx = m.logger.WithContext(ctx)
+	rw := &responseRecorder{ResponseWriter: w}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rw.status == 0 {
+				rw.status = http.StatusInternalServerError
+			}
+			m.log(ctx, r, rw, start)
+			panic(p)
+		}
+	}()
+	next.ServeHTTP(rw, r.WithContext(ctx))
+	m.log(ctx, r, rw, start)
+}
+
+// log 记录访问日志。
+func (m *httpMiddleware) log(ctx context.Context, r *http.Request, rw *responseRecorder, start time.Time) {
+	if _, ok := m.skip[r.URL.Path]; ok {
+		return
+	}
+
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	fields := []Field{
+		String("method", r.Method),
+		String("path", r.URL.Path),
+		Int("status", status),
+		Int64("bytes", rw.bytes),
+		Duration("latency", time.Since(start)),
+		String("remote-ip", remoteIP(r.RemoteAddr)),
+		String("user-agent", r.UserAgent()),
+	}
+	if len(m.headers) > 0 {
+		fields = append(fields, zap.Object("headers", capturedHeaders{header: r.Header, names: m.headers}))
+	}
+
+	logAt(ctx, m.logger, m.level(status), "HTTP request", fields)
+}
+
+// logAt 使用 logger 以 level 级别记录带有 ctx 中字段的日志，高于 Error 的级别按 Error 记录。
+func logAt(ctx context.Context, logger Logger, level Level, msg string, fields []Field) {
+	switch {
+	case level <= DebugLevel:
+		logger.DebugContext(ctx, msg, fields...)
+	case level == InfoLevel:
+		logger.InfoContext(ctx, msg, fields...)
+	case level == WarnLevel:
+		logger.WarnContext(ctx, msg, fields...)
+	default:
+		logger.ErrorContext(ctx, msg, fields...)
+	}
+}
+
+// validRequestID 返回 id 是否可以作为请求 ID：非空、不超过 maxRequestIDLength 并且只包含可打印的 ASCII 字符。
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID 返回随机生成的 32 位十六进制请求 ID。
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(b[:])
+}
+
+// remoteIP 返回 http.Request.RemoteAddr 中的 IP。
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
+
+// capturedHeaders 将请求头 names 编码为以小写的请求头名称为 key 的对象，多个值以逗号分隔。
+type capturedHeaders struct {
+	header http.Header
+	names  []string
+}
+
+func (h capturedHeaders) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, name := range h.names {
+		if values := h.header.Values(name); len(values) > 0 {
+			enc.AddString(strings.ToLower(name), strings.Join(values, ", "))
+		}
+	}
+
+	return nil
+}
+
+// responseRecorder 记录响应的状态码和写入的字节数。
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	// 1xx 是临时响应，之后还会写入最终的状态码
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+
+	return n, err
+}
+
+// Flush 实现 http.Flusher，底层的 ResponseWriter 不支持时什么也不做。
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack 实现 http.Hijacker，底层的 ResponseWriter 不支持时返回错误。
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+
+	return h.Hijack()
+}
+
+// Unwrap 返回底层的 ResponseWriter，供 http.ResponseController 使用。
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,196 @@
+package log
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core))
+
+	var handlerID interface{}
+	handler := HTTPMiddleware(logger, HTTPCaptureHeaders("x-forwarded-for", "X-Missing"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerID = r.Context().Value(KeyRequestID)
+			FromContext(r.Context()).Info("handling")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, "hello")
+		}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users?token=secret", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set(HeaderRequestID, "abc-123")
+	req.Header.Add("X-Forwarded-For", "198.51.100.1")
+	req.Header.Add("X-Forwarded-For", "198.51.100.2")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "abc-123", w.Header().Get(HeaderRequestID))
+	assert.Equal(t, "abc-123", handlerID)
+
+	got := logs.AllUntimed()
+	if !assert.Len(t, got, 2) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{"request-id": "abc-123"}, got[0].ContextMap())
+
+	access := got[1]
+	assert.Equal(t, zapcore.InfoLevel, access.Level)
+	assert.Equal(t, "HTTP request", access.Message)
+	fields := access.ContextMap()
+	assert.IsType(t, time.Duration(0), fields["latency"])
+	delete(fields, "latency")
+	assert.Equal(t, map[string]interface{}{
+		"request-id": "abc-123",
+		"method":     http.MethodPost,
+		"path":       "/users",
+		"status":     int64(http.StatusCreated),
+		"bytes":      int64(5),
+		"remote-ip":  "192.0.2.1",
+		"user-agent": "test-agent",
+		"headers":    map[string]interface{}{"x-forwarded-for": "198.51.100.1, 198.51.100.2"},
+	}, fields)
+}
+
+func TestHTTPMiddleware_requestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		generate bool
+	}{
+		{name: "missing", header: "", generate: true},
+		{name: "propagated", header: "req-1", generate: false},
+		{name: "too long", header: strings.Repeat("a", maxRequestIDLength+1), generate: true},
+		{name: "control character", header: "req\x01", generate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			handler := HTTPMiddleware(NewLogger(zap.New(core)))(http.NotFoundHandler())
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderRequestID, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(HeaderRequestID)
+			if tt.generate {
+				assert.Len(t, id, 32)
+			} else {
+				assert.Equal(t, tt.header, id)
+			}
+			assert.Equal(t, id, logs.All()[0].ContextMap()["request-id"])
+		})
+	}
+}
+
+func TestHTTPMiddleware_level(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		opts   []HTTPMiddlewareOption
+		want   zapcore.Level
+	}{
+		{name: "ok", status: http.StatusOK, want: zapcore.InfoLevel},
+		{name: "client error", status: http.StatusNotFound, want: zapcore.WarnLevel},
+		{name: "server error", status: http.StatusBadGateway, want: zapcore.ErrorLevel},
+		{
+			name:   "custom",
+			status: http.StatusNotFound,
+			opts: []HTTPMiddlewareOption{HTTPStatusLevel(func(status int) Level {
+				return DebugLevel
+			})},
+			want: zapcore.DebugLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			handler := HTTPMiddleware(NewLogger(zap.New(core)), tt.opts...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(tt.status)
+				}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if assert.Equal(t, 1, logs.Len()) {
+				assert.Equal(t, tt.want, logs.All()[0].Level)
+				assert.Equal(t, int64(tt.status), logs.All()[0].ContextMap()["status"])
+			}
+		})
+	}
+}
+
+func TestHTTPMiddleware_skipPaths(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	handler := HTTPMiddleware(NewLogger(zap.New(core)), HTTPSkipPaths("/healthz"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.NotEmpty(t, w.Header().Get(HeaderRequestID))
+	assert.Equal(t, 0, logs.Len())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz/deep", nil))
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestHTTPMiddleware_panic(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	handler := HTTPMiddleware(NewLogger(zap.New(core)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+	assert.PanicsWithValue(t, "boom", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	if assert.Equal(t, 1, logs.Len()) {
+		assert.Equal(t, zapcore.ErrorLevel, logs.All()[0].Level)
+		assert.Equal(t, int64(http.StatusInternalServerError), logs.All()[0].ContextMap()["status"])
+	}
+}
+
+func TestHTTPMiddleware_latencyEncoding(t *testing.T) {
+	buf := &strings.Builder{}
+	enc := zapcore.NewJSONEncoder(NewOptions().encoderConfig())
+	logger := NewLogger(zap.New(zapcore.NewCore(enc, zapcore.AddSync(buf), zapcore.DebugLevel)))
+	handler := HTTPMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Regexp(t, `"latency":[0-9]+\.?[0-9]*[,}]`, buf.String())
+}
+
+func Test_responseRecorder(t *testing.T) {
+	rw := &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+	rw.WriteHeader(http.StatusEarlyHints)
+	assert.Equal(t, 0, rw.status, "1xx responses are not final")
+
+	w := httptest.NewRecorder()
+	rw = &responseRecorder{ResponseWriter: w}
+	rw.Flush()
+	_, _ = rw.Write([]byte("abc"))
+	rw.WriteHeader(http.StatusTeapot)
+
+	assert.Equal(t, http.StatusOK, rw.status)
+	assert.Equal(t, int64(3), rw.bytes)
+	assert.True(t, w.Flushed)
+	assert.Same(t, w, rw.Unwrap())
+	_, _, err := rw.Hijack()
+	assert.Error(t, err)
+}
@@ -3,7 +3,12 @@ package log
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"go.uber.org/zap"
@@ -11,16 +16,41 @@ import (
 )
 
 const (
-	flagLevel             = "log.level"
-	flagDisableCaller     = "log.disable-caller"
-	flagDisableStacktrace = "log.disable-stacktrace"
-	flagFormat            = "log.format"
-	flagEnableColor       = "log.enable-color"
-	flagEncodeFullCaller  = "log.enable-full-caller"
-	flagOutputPaths       = "log.output-paths"
-	flagErrorOutputPaths  = "log.error-output-paths"
-	flagDevelopment       = "log.development"
-	flagName              = "log.name"
+	flagLevel               = "log.level"
+	flagLevelOverrides      = "log.level-overrides"
+	flagVerbosity           = "log.verbosity"
+	flagVModule             = "log.vmodule"
+	flagDisableCaller       = "log.disable-caller"
+	flagDisableStacktrace   = "log.disable-stacktrace"
+	flagFormat              = "log.format"
+	flagEnableColor         = "log.enable-color"
+	flagEncodeFullCaller    = "log.enable-full-caller"
+	flagOutputPaths         = "log.output-paths"
+	flagErrorOutputPaths    = "log.error-output-paths"
+	flagDevelopment         = "log.development"
+	flagName                = "log.name"
+	flagMaxSize             = "log.max-size"
+	flagMaxAge              = "log.max-age"
+	flagMaxBackups          = "log.max-backups"
+	flagMaxTotalSize        = "log.max-total-size"
+	flagCompress            = "log.compress"
+	flagRotateDaily         = "log.rotate-daily"
+	flagLocalTime           = "log.local-time"
+	flagAsync               = "log.async"
+	flagAsyncBufferSize     = "log.async-buffer-size"
+	flagAsyncOverflow       = "log.async-overflow"
+	flagAsyncDropLevel      = "log.async-drop-level"
+	flagDisableSampling     = "log.disable-sampling"
+	flagSamplingTick        = "log.sampling-tick"
+	flagSamplingInitial     = "log.sampling-initial"
+	flagSamplingThereafter  = "log.sampling-thereafter"
+	flagSamplingExemptLevel = "log.sampling-exempt-level"
+	flagSlogDefault         = "log.slog-default"
+	flagRedactKeys          = "log.redact-keys"
+	flagRedactKeyPatterns   = "log.redact-key-patterns"
+	flagRedactValues        = "log.redact-values"
+	flagRedactValuePatterns = "log.redact-value-patterns"
+	flagRedactMask          = "log.redact-mask"
 
 	consoleFormat = "console"
 	jsonFormat    = "json"
@@ -28,54 +58,238 @@ const (
 
 // Options 日志相关的配置项。
 type Options struct {
-	OutputPaths       []string `json:"output-paths"       mapstructure:"output-paths"`
-	ErrorOutputPaths  []string `json:"error-output-paths" mapstructure:"error-output-paths"`
-	Level             string   `json:"level"              mapstructure:"level"`
-	Format            string   `json:"format"             mapstructure:"format"`
-	DisableCaller     bool     `json:"disable-caller"     mapstructure:"disable-caller"`
-	DisableStacktrace bool     `json:"disable-stacktrace" mapstructure:"disable-stacktrace"`
-	EnableColor       bool     `json:"enable-color"       mapstructure:"enable-color"`
-	EncodeFullCaller  bool     `json:"enable-full-caller" mapstructure:"enable-full-caller"`
-	Development       bool     `json:"development"        mapstructure:"development"`
-	Name              string   `json:"name"               mapstructure:"name"`
+	OutputPaths         []string          `json:"output-paths"          mapstructure:"output-paths"`
+	ErrorOutputPaths    []string          `json:"error-output-paths"    mapstructure:"error-output-paths"`
+	Level               string            `json:"level"                 mapstructure:"level"`
+	LevelOverrides      map[string]string `json:"level-overrides"       mapstructure:"level-overrides"`
+	Verbosity           int               `json:"verbosity"             mapstructure:"verbosity"`
+	VModule             string            `json:"vmodule"               mapstructure:"vmodule"`
+	Format              string            `json:"format"                mapstructure:"format"`
+	DisableCaller       bool              `json:"disable-caller"        mapstructure:"disable-caller"`
+	DisableStacktrace   bool              `json:"disable-stacktrace"    mapstructure:"disable-stacktrace"`
+	EnableColor         bool              `json:"enable-color"          mapstructure:"enable-color"`
+	EncodeFullCaller    bool              `json:"enable-full-caller"    mapstructure:"enable-full-caller"`
+	Development         bool              `json:"development"           mapstructure:"development"`
+	Name                string            `json:"name"                  mapstructure:"name"`
+	MaxSize             int               `json:"max-size"              mapstructure:"max-size"`
+	MaxAge              int               `json:"max-age"               mapstructure:"max-age"`
+	MaxBackups          int               `json:"max-backups"           mapstructure:"max-backups"`
+	MaxTotalSize        int               `json:"max-total-size"        mapstructure:"max-total-size"`
+	Compress            string            `json:"compress"              mapstructure:"compress"`
+	RotateDaily         bool              `json:"rotate-daily"          mapstructure:"rotate-daily"`
+	LocalTime           bool              `json:"local-time"            mapstructure:"local-time"`
+	Async               bool              `json:"async"                 mapstructure:"async"`
+	AsyncBufferSize     int               `json:"async-buffer-size"     mapstructure:"async-buffer-size"`
+	AsyncOverflow       string            `json:"async-overflow"        mapstructure:"async-overflow"`
+	AsyncDropLevel      string            `json:"async-drop-level"      mapstructure:"async-drop-level"`
+	DisableSampling     bool              `json:"disable-sampling"      mapstructure:"disable-sampling"`
+	SamplingTick        time.Duration     `json:"sampling-tick"         mapstructure:"sampling-tick"`
+	SamplingInitial     int               `json:"sampling-initial"      mapstructure:"sampling-initial"`
+	SamplingThereafter  int               `json:"sampling-thereafter"   mapstructure:"sampling-thereafter"`
+	SamplingExemptLevel string            `json:"sampling-exempt-level" mapstructure:"sampling-exempt-level"`
+	SlogDefault         bool              `json:"slog-default"          mapstructure:"slog-default"`
+	RedactKeys          []string          `json:"redact-keys"           mapstructure:"redact-keys"`
+	RedactKeyPatterns   []string          `json:"redact-key-patterns"   mapstructure:"redact-key-patterns"`
+	RedactValues        []string          `json:"redact-values"         mapstructure:"redact-values"`
+	RedactValuePatterns []string          `json:"redact-value-patterns" mapstructure:"redact-value-patterns"`
+	RedactMask          string            `json:"redact-mask"           mapstructure:"redact-mask"`
+
+	// SamplingHook 在每次采样决定后调用，可用于统计被采样丢弃的日志。
+	SamplingHook func(zapcore.Entry, zapcore.SamplingDecision) `json:"-"                     mapstructure:"-"`
+
+	// envErrs 记录 LoadEnv 解析环境变量时遇到的错误，由 Validate 返回。
+	envErrs []*FieldError
 }
 
+// setSlogDefault 使用 l 设置 slog 的默认 logger，返回恢复原来的默认 logger 的函数，仅在 Go 1.21 及以上版本可用。
+var setSlogDefault func(l *zap.Logger) (restore func())
+
 // NewOptions 创建一个带有默认参数的 Options 对象。
 func NewOptions() *Options {
 	return &Options{
-		Level:             zapcore.InfoLevel.String(),
-		DisableCaller:     false,
-		DisableStacktrace: false,
-		Format:            consoleFormat,
-		EnableColor:       true,
-		EncodeFullCaller:  false,
-		Development:       false,
-		OutputPaths:       []string{"stdout"},
-		ErrorOutputPaths:  []string{"stderr"},
+		Level:               zapcore.InfoLevel.String(),
+		DisableCaller:       false,
+		DisableStacktrace:   false,
+		Format:              consoleFormat,
+		EnableColor:         true,
+		EncodeFullCaller:    false,
+		Development:         false,
+		OutputPaths:         []string{"stdout"},
+		ErrorOutputPaths:    []string{"stderr"},
+		AsyncBufferSize:     defaultAsyncBufferSize,
+		AsyncOverflow:       OverflowBlock,
+		AsyncDropLevel:      zapcore.WarnLevel.String(),
+		SamplingTick:        defaultSamplingTick,
+		SamplingInitial:     defaultSamplingInitial,
+		SamplingThereafter:  defaultSamplingThereafter,
+		SamplingExemptLevel: zapcore.ErrorLevel.String(),
+		RedactMask:          RedactMaskFull,
 	}
 }
 
 // Validate 验证选项字段。
 func (o *Options) Validate() []error {
 	var errs []error
+	for _, err := range o.validate() {
+		errs = append(errs, err.Err)
+	}
+
+	return errs
+}
+
+// validate 验证选项字段，返回的错误带有出错的配置项名称。
+func (o *Options) validate() []*FieldError {
+	var errs []*FieldError
+	addErr := func(path string, err error) {
+		errs = append(errs, &FieldError{Path: path, Err: err})
+	}
+
+	errs = append(errs, o.envErrs...)
+
+	if len(o.OutputPaths) == 0 {
+		addErr("output-paths", fmt.Errorf("output paths must not be empty"))
+	}
+	for _, path := range o.OutputPaths {
+		if !isOTLPPath(path) {
+			continue
+		}
+		if _, err := parseOTLPURL(path); err != nil {
+			addErr("output-paths", err)
+		}
+	}
+	if len(o.ErrorOutputPaths) == 0 {
+		addErr("error-output-paths", fmt.Errorf("error output paths must not be empty"))
+	}
 
 	var zapLevel zapcore.Level
 	if err := zapLevel.UnmarshalText([]byte(o.Level)); err != nil {
-		errs = append(errs, err)
+		addErr("level", err)
+	}
+
+	if _, overrideErrs := parseLevelOverrides(o.LevelOverrides); len(overrideErrs) > 0 {
+		for _, err := range overrideErrs {
+			addErr("level-overrides", err)
+		}
+	}
+
+	if o.Verbosity < 0 {
+		addErr("verbosity", fmt.Errorf("verbosity must not be negative: %d", o.Verbosity))
+	}
+	if _, err := parseVModule(o.VModule); err != nil {
+		addErr("vmodule", err)
 	}
 
 	format := strings.ToLower(o.Format)
 	if format != consoleFormat && format != jsonFormat {
-		errs = append(errs, fmt.Errorf("not a valid log format: %q", o.Format))
+		addErr("format", fmt.Errorf("not a valid log format: %q", o.Format))
+	}
+
+	for _, path := range negativeFields(map[string]int{
+		"max-size": o.MaxSize, "max-age": o.MaxAge, "max-backups": o.MaxBackups, "max-total-size": o.MaxTotalSize,
+	}) {
+		addErr(path, fmt.Errorf("log rotation settings must not be negative"))
+	}
+
+	if _, ok := compressExts[o.Compress]; !ok && o.Compress != compressNone {
+		addErr("compress", fmt.Errorf("not a valid compression format: %q", o.Compress))
+	}
+	// 压缩和清理只作用于滚动后的文件，没有开启滚动时不会生效
+	if !o.rotateEnabled() {
+		for _, path := range positiveFields(map[string]int{
+			"max-age": o.MaxAge, "max-backups": o.MaxBackups, "max-total-size": o.MaxTotalSize,
+		}) {
+			addErr(path, fmt.Errorf("%s requires max-size or rotate-daily to be set", path))
+		}
+		if o.Compress != compressNone {
+			addErr("compress", fmt.Errorf("compress requires max-size or rotate-daily to be set"))
+		}
+	}
+
+	if o.AsyncBufferSize < 0 {
+		addErr("async-buffer-size", fmt.Errorf("async buffer size must not be negative: %d", o.AsyncBufferSize))
+	}
+	switch o.AsyncOverflow {
+	case "", OverflowBlock, OverflowDropNewest, OverflowDropOldest, OverflowDropBelowLevel:
+	default:
+		addErr("async-overflow", fmt.Errorf("not a valid async overflow policy: %q", o.AsyncOverflow))
+	}
+	if o.AsyncDropLevel != "" {
+		var dropLevel zapcore.Level
+		if err := dropLevel.UnmarshalText([]byte(o.AsyncDropLevel)); err != nil {
+			addErr("async-drop-level", err)
+		}
+	}
+
+	if !o.DisableSampling {
+		for _, path := range negativeFields(map[string]int{
+			"sampling-tick": int(o.SamplingTick), "sampling-initial": o.SamplingInitial,
+			"sampling-thereafter": o.SamplingThereafter,
+		}) {
+			addErr(path, fmt.Errorf("sampling settings must not be negative"))
+		}
+		if o.SamplingExemptLevel != "" {
+			var exempt zapcore.Level
+			if err := exempt.UnmarshalText([]byte(o.SamplingExemptLevel)); err != nil {
+				addErr("sampling-exempt-level", err)
+			}
+		}
+	}
+
+	_, redactErrs := parseRedactor(o)
+	errs = append(errs, redactErrs...)
+
+	if o.SlogDefault && setSlogDefault == nil {
+		addErr("slog-default", fmt.Errorf("slog-default requires Go 1.21 or later"))
 	}
 
 	return errs
 }
 
+// negativeFields 按名称排序返回所有值为负数的配置项名称。
+func negativeFields(values map[string]int) []string {
+	var names []string
+	for name, v := range values {
+		if v < 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// positiveFields 按名称排序返回所有值为正数的配置项名称。
+func positiveFields(values map[string]int) []string {
+	var names []string
+	for name, v := range values {
+		if v > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 // AddFlags 将 Options 的各个字段追加到传入的 pflag.FlagSet 变量中。
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.Level, flagLevel, o.Level,
 		"日志级别，优先级从低到高依次为: Debug, Info, Warn, Error, Dpanic, Panic, Fatal。")
+	fs.StringToStringVar(&o.LevelOverrides, flagLevelOverrides, o.LevelOverrides,
+		"按 logger 名称前缀覆盖日志级别，如 db=debug,api.handler=warn，最长匹配的前缀生效。"+
+			"名称不包括 name 选项设置的前缀，如 name 为 svc 时 db 匹配 svc.db。"+
+			"级别也可以是数字 N，表示以 Info 级别输出并启用 V(N)。")
+	// 应用自己的 FlagSet 可能已经使用了 -v，此时只注册完整的 flag 名称，避免 pflag panic
+	verbosityShorthand := "v"
+	if fs.ShorthandLookup(verbosityShorthand) != nil {
+		verbosityShorthand = ""
+	}
+	fs.IntVarP(&o.Verbosity, flagVerbosity, verbosityShorthand, o.Verbosity,
+		"V(N) 日志的详细级别，启用所有不大于该值的 V(N)，与日志级别相互独立，V 日志以 Info 级别输出。"+
+			"FlagSet 中没有其他 -v flag 时可以使用 -v 设置。")
+	fs.StringVar(&o.VModule, flagVModule, o.VModule,
+		"按调用 V 的源文件覆盖详细级别，如 server=2,pkg/db/*=3。模式不含 / 时匹配文件名（不含 .go），"+
+			"否则匹配以 / 分隔的路径后缀，第一个匹配的模式生效。")
 	fs.BoolVar(&o.DisableCaller, flagDisableCaller, o.DisableCaller,
 		"是否开启 caller，如果开启会在日志中显示调用日志所在的文件、函数和行号。")
 	fs.BoolVar(&o.DisableStacktrace, flagDisableStacktrace,
@@ -86,12 +300,54 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&o.EncodeFullCaller, flagEncodeFullCaller, o.EncodeFullCaller,
 		"是否开启 full caller /full/path/to/package/file:line，true:是，false:否")
 	fs.StringSliceVar(&o.OutputPaths, flagOutputPaths, o.OutputPaths,
-		"支持输出到多个输出，用逗号分开。支持输出到标准输出(stdout)和文件。")
+		"支持输出到多个输出，用逗号分开。支持输出到标准输出(stdout)、文件和 OpenTelemetry collector，"+
+			"如 otlp+grpc://localhost:4317 或 otlp+http://localhost:4318/v1/logs。")
 	fs.StringSliceVar(&o.ErrorOutputPaths, flagErrorOutputPaths, o.ErrorOutputPaths,
 		"zap 内部 (非业务) 错误日志输出路径，多个输出，用逗号分开")
 	fs.BoolVar(&o.Development, flagDevelopment, o.Development,
 		"是否是开发模式。如果是开发模式，会对 DPanicLevel 进行堆栈跟踪。")
 	fs.StringVar(&o.Name, flagName, o.Name, "Logger 的名字。")
+	fs.IntVar(&o.MaxSize, flagMaxSize, o.MaxSize,
+		"单个日志文件的最大大小 (MB)，超过后滚动，0 表示不按大小滚动。仅对输出到文件生效。")
+	fs.IntVar(&o.MaxAge, flagMaxAge, o.MaxAge,
+		"滚动后的日志文件最多保留的天数，0 表示不按时间清理。需要同时设置 max-size 或 rotate-daily。")
+	fs.IntVar(&o.MaxBackups, flagMaxBackups, o.MaxBackups,
+		"滚动后的日志文件最多保留的个数，0 表示全部保留。需要同时设置 max-size 或 rotate-daily。")
+	fs.IntVar(&o.MaxTotalSize, flagMaxTotalSize, o.MaxTotalSize,
+		"日志文件 (包括当前文件和滚动后的文件) 占用磁盘的总大小上限 (MB)，超过后从最旧的文件开始删除，0 表示不限制。"+
+			"需要同时设置 max-size 或 rotate-daily。")
+	fs.StringVar(&o.Compress, flagCompress, o.Compress,
+		"滚动后的日志文件在后台使用的压缩格式，目前支持 gzip 和 zstd，为空表示不压缩。需要同时设置 max-size 或 rotate-daily。")
+	fs.BoolVar(&o.RotateDaily, flagRotateDaily, o.RotateDaily, "是否在每天零点滚动日志文件。")
+	fs.BoolVar(&o.Async, flagAsync, o.Async, "是否开启异步写入，开启后日志先编码放入有界队列，由后台 goroutine 写入输出。")
+	fs.IntVar(&o.AsyncBufferSize, flagAsyncBufferSize, o.AsyncBufferSize, "异步写入队列最多缓存的日志条数。")
+	fs.StringVar(&o.AsyncOverflow, flagAsyncOverflow, o.AsyncOverflow,
+		"异步写入队列已满时的处理策略，支持 block, drop-newest, drop-oldest 和 drop-below-level。")
+	fs.StringVar(&o.AsyncDropLevel, flagAsyncDropLevel, o.AsyncDropLevel,
+		"策略为 drop-below-level 时，队列已满会丢弃低于该级别的日志，其余日志阻塞等待。")
+	fs.BoolVar(&o.DisableSampling, flagDisableSampling, o.DisableSampling, "是否关闭日志采样，关闭后所有日志都会输出。")
+	fs.DurationVar(&o.SamplingTick, flagSamplingTick, o.SamplingTick, "日志采样的统计周期，0 表示使用默认值 1s。")
+	fs.IntVar(&o.SamplingInitial, flagSamplingInitial, o.SamplingInitial,
+		"每个统计周期内，相同级别和内容的日志先全部输出的条数，0 表示使用默认值 100。")
+	fs.IntVar(&o.SamplingThereafter, flagSamplingThereafter, o.SamplingThereafter,
+		"超过 sampling-initial 条后，相同级别和内容的日志每隔多少条输出一条，0 表示使用默认值 100。")
+	fs.StringVar(&o.SamplingExemptLevel, flagSamplingExemptLevel, o.SamplingExemptLevel,
+		"不参与采样的最低日志级别，该级别及以上的日志总会输出。")
+	fs.BoolVar(&o.SlogDefault, flagSlogDefault, o.SlogDefault,
+		"Build 时是否同时将 slog 的默认 logger 设置为使用该日志配置，需要 Go 1.21 及以上版本。")
+	fs.StringSliceVar(&o.RedactKeys, flagRedactKeys, o.RedactKeys,
+		"需要脱敏的字段名称，多个名称用逗号分开。不区分大小写，并忽略 -、_ 和 . 等分隔符。"+
+			"默认不脱敏，设置了任意脱敏规则后，结构体中带有 `log:\"redact\"` 标签的字段同样会被脱敏。")
+	fs.StringSliceVar(&o.RedactKeyPatterns, flagRedactKeyPatterns, o.RedactKeyPatterns,
+		"需要脱敏的字段名称的正则表达式，不区分大小写，多个表达式用逗号分开。")
+	fs.StringSliceVar(&o.RedactValues, flagRedactValues, o.RedactValues,
+		"按值脱敏的内置规则，目前支持 jwt, credit-card 和 email，多个规则用逗号分开。")
+	fs.StringSliceVar(&o.RedactValuePatterns, flagRedactValuePatterns, o.RedactValuePatterns,
+		"按值脱敏的正则表达式，字符串字段中匹配的部分会被脱敏，多个表达式用逗号分开。")
+	fs.StringVar(&o.RedactMask, flagRedactMask, o.RedactMask,
+		"脱敏后的值的显示方式，支持 full (全部替换), partial (保留首尾部分) 和 hash (SHA-256 摘要)。")
+	fs.BoolVar(&o.LocalTime, flagLocalTime, o.LocalTime,
+		"滚动的零点以及备份文件名中的时间是否使用本地时间，false 表示使用 UTC 时间。")
 }
 
 // String 将 Options 的值以 JSON 格式字符串返回。
@@ -100,56 +356,247 @@ func (o *Options) String() string {
 	return string(data)
 }
 
-// Build 根据 Options 构建一个全局的 Logger。
+// Build 根据 Options 构建 logger 并替换全局 logger，与 Init 不同的是配置无效时返回错误。
 func (o *Options) Build() error {
-	var zapLevel zapcore.Level
-	if err := zapLevel.UnmarshalText([]byte(o.Level)); err != nil {
-		zapLevel = zapcore.InfoLevel
+	l, err := newLogger(o)
+	if err != nil {
+		return err
+	}
+	o.install(l)
+
+	return nil
+}
+
+// install 将 l 设置为全局 logger，同时替换 Zap 的全局 logger 并重定向标准库的日志，
+// 返回按相反的顺序恢复这些设置的函数。
+func (o *Options) install(l *zapLogger) (restore func()) {
+	restores := []func(){ReplaceGlobal(l)}
+
+	base := l.direct()
+	if o.SlogDefault && setSlogDefault != nil {
+		restores = append(restores, setSlogDefault(base))
+	}
+	restores = append(restores, redirectStdLog(l), zap.ReplaceGlobals(base))
+
+	return func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}
+}
+
+// zapConfig 返回创建 logger 使用的 zap.Config，级别由 levelCore 过滤。
+func (o *Options) zapConfig() *zap.Config {
+	return &zap.Config{
+		Level:             zap.NewAtomicLevelAt(lowestLevel),
+		Development:       o.Development,
+		DisableCaller:     o.DisableCaller,
+		DisableStacktrace: o.DisableStacktrace,
+		Encoding:          o.Format,
+		EncoderConfig:     o.encoderConfig(),
+		OutputPaths:       o.outputPaths(),
+		ErrorOutputPaths:  o.ErrorOutputPaths,
 	}
+}
+
+// encoderConfig 返回 Options 对应的 EncoderConfig。
+func (o *Options) encoderConfig() zapcore.EncoderConfig {
 	encodeLevel := zapcore.CapitalLevelEncoder
+	// 当输出到本地路径时，禁止使用颜色
 	if o.Format == consoleFormat && o.EnableColor {
 		encodeLevel = zapcore.CapitalColorLevelEncoder
 	}
 
-	var encodeCaller zapcore.CallerEncoder
+	encodeCaller := zapcore.ShortCallerEncoder
 	if o.EncodeFullCaller {
 		encodeCaller = zapcore.FullCallerEncoder
+	}
+
+	return zapcore.EncoderConfig{
+		MessageKey:     "message",
+		LevelKey:       "level",
+		TimeKey:        "timestamp",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    encodeLevel,
+		EncodeTime:     timeEncoder,
+		EncodeDuration: milliSecondsDurationEncoder,
+		EncodeCaller:   encodeCaller,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+}
+
+// Encoder 返回按 Options 中的 Format、EnableColor 和 EncodeFullCaller 编码日志的 Encoder，
+// 用于在自定义的 zapcore.Core 中使用与 New 相同的日志格式。
+func (o *Options) Encoder() (zapcore.Encoder, error) {
+	return newEncoder(o.Format, o.encoderConfig())
+}
+
+// newEncoder 根据格式名称创建 Encoder。
+func newEncoder(format string, encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch format {
+	case consoleFormat:
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	case jsonFormat:
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	}
+
+	return nil, fmt.Errorf("no encoder registered for name %q", format)
+}
+
+// buildConfig 与 zap.Config.Build 相同，开启异步写入时日志在调用方 goroutine 中编码，
+// 再放入有界队列由后台 goroutine 写入输出。OTLP 输出不经过 zap.Open，由单独的 Core 导出。
+// 返回的 closer 用于关闭异步写入以及打开的输出。
+func (o *Options) buildConfig(cfg *zap.Config, opts ...zap.Option) (*zap.Logger, func() error, error) {
+	r, err := newRedactor(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc, err := newEncoder(cfg.Encoding, cfg.EncoderConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths, otlpPaths := splitOTLPPaths(cfg.OutputPaths)
+	sink, closeOut, err := zap.Open(paths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 错误输出只打开一次，由返回的 closer 关闭
+	errSink, closeErrOut, err := zap.Open(cfg.ErrorOutputPaths...)
+	if err != nil {
+		closeOut()
+		return nil, nil, err
+	}
+
+	// 脱敏在最内层进行，保证 With 添加的字段和写入的字段都经过脱敏
+	var core zapcore.Core
+	closer := func() error {
+		closeOut()
+		closeErrOut()
+		return nil
+	}
+	if !o.Async {
+		core = r.wrap(zapcore.NewCore(enc, sink, cfg.Level))
 	} else {
-		encodeCaller = zapcore.ShortCallerEncoder
+		dropLevel := zapcore.WarnLevel
+		if o.AsyncDropLevel != "" {
+			if err = dropLevel.UnmarshalText([]byte(o.AsyncDropLevel)); err != nil {
+				_ = closer()
+				return nil, nil, err
+			}
+		}
+
+		w := newAsyncWriter(sink, errSink, o.AsyncBufferSize, o.AsyncOverflow)
+		core = &asyncCore{Core: r.wrap(zapcore.NewCore(enc, w, cfg.Level)), w: w, dropLevel: dropLevel}
+		closeSinks := closer
+		closer = func() error {
+			err := w.Close()
+			_ = closeSinks()
+
+			return err
+		}
 	}
 
-	zc := &zap.Config{
-		Level:             zap.NewAtomicLevelAt(zapLevel),
-		Development:       o.Development,
-		DisableCaller:     o.DisableCaller,
-		DisableStacktrace: o.DisableStacktrace,
-		Sampling: &zap.SamplingConfig{
-			Initial:    100,
-			Thereafter: 100,
-		},
-		Encoding: o.Format,
-		EncoderConfig: zapcore.EncoderConfig{
-			MessageKey:     "message",
-			LevelKey:       "level",
-			TimeKey:        "timestamp",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    encodeLevel,
-			EncodeTime:     timeEncoder,
-			EncodeDuration: milliSecondsDurationEncoder,
-			EncodeCaller:   encodeCaller,
-			EncodeName:     zapcore.FullNameEncoder,
-		},
-		OutputPaths:      o.OutputPaths,
-		ErrorOutputPaths: o.ErrorOutputPaths,
-	}
-	logger, err := zc.Build(zap.AddStacktrace(zapcore.PanicLevel))
+	if len(otlpPaths) > 0 {
+		otlp, closeOTLP, err := o.newOTLPCore(otlpPaths, cfg.Level, errSink)
+		if err != nil {
+			_ = closer()
+			return nil, nil, err
+		}
+		otlpCore := r.wrap(otlp)
+		if len(paths) == 0 {
+			core = otlpCore
+		} else {
+			core = zapcore.NewTee(core, otlpCore)
+		}
+		closeSinks := closer
+		closer = func() error {
+			err := closeOTLP()
+			if closeErr := closeSinks(); err == nil {
+				err = closeErr
+			}
+
+			return err
+		}
+	}
+
+	// 输出和错误输出已经在上面打开，剩余的选项仍然交给 zap.Config 处理
+	syncCfg := *cfg
+	syncCfg.OutputPaths = nil
+	syncCfg.ErrorOutputPaths = nil
+	opts = append([]zap.Option{
+		zap.WrapCore(func(zapcore.Core) zapcore.Core { return core }),
+		zap.ErrorOutput(errSink),
+	}, opts...)
+	l, err := syncCfg.Build(opts...)
 	if err != nil {
-		return err
+		_ = closer()
+		return nil, nil, err
 	}
-	zap.RedirectStdLog(logger.Named(o.Name))
-	zap.ReplaceGlobals(logger)
-	return nil
+
+	return l, closer, nil
+}
+
+// rotateEnabled 返回是否开启了日志文件滚动。
+func (o *Options) rotateEnabled() bool {
+	return o.MaxSize > 0 || o.RotateDaily
+}
+
+// outputPaths 返回交给 zap 的输出路径，开启滚动时将文件路径转换为 rotate 链接。
+func (o *Options) outputPaths() []string {
+	if !o.rotateEnabled() {
+		return o.OutputPaths
+	}
+
+	query := url.Values{}
+	query.Set("max-size", strconv.Itoa(o.MaxSize))
+	query.Set("max-age", strconv.Itoa(o.MaxAge))
+	query.Set("max-backups", strconv.Itoa(o.MaxBackups))
+	query.Set("max-total-size", strconv.Itoa(o.MaxTotalSize))
+	query.Set("compress", o.Compress)
+	query.Set("rotate-daily", strconv.FormatBool(o.RotateDaily))
+	query.Set("local-time", strconv.FormatBool(o.LocalTime))
+
+	paths := make([]string, 0, len(o.OutputPaths))
+	for _, path := range o.OutputPaths {
+		filename, ok := localFilePath(path)
+		if !ok {
+			paths = append(paths, path)
+			continue
+		}
+		u := url.URL{Scheme: rotateScheme, Path: filename, RawQuery: query.Encode()}
+		paths = append(paths, u.String())
+	}
+
+	return paths
+}
+
+// localFilePath 判断 path 是否指向本地文件，如果是则返回其绝对路径。
+func localFilePath(path string) (string, bool) {
+	if path == "stdout" || path == "stderr" {
+		return "", false
+	}
+
+	if !filepath.IsAbs(path) {
+		u, err := url.Parse(path)
+		if err != nil {
+			return "", false
+		}
+		switch u.Scheme {
+		case "":
+		case "file":
+			path = u.Path
+		default:
+			return "", false
+		}
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+
+	return abs, true
 }
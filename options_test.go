@@ -2,10 +2,14 @@ package log
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 func TestNewOptions(t *testing.T) {
@@ -80,7 +84,26 @@ func TestOptions_AddFlags(t *testing.T) {
 	}
 }
 
+func TestOptions_AddFlags_verbosityShorthand(t *testing.T) {
+	o := NewOptions()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	o.AddFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"-v", "2"}))
+	assert.Equal(t, 2, o.Verbosity)
+
+	// 应用已经使用了 -v 时只注册完整的 flag 名称
+	o = NewOptions()
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	version := fs.BoolP("version", "v", false, "print version")
+	assert.NotPanics(t, func() { o.AddFlags(fs) })
+	assert.NoError(t, fs.Parse([]string{"-v", "--log.verbosity=3"}))
+	assert.True(t, *version)
+	assert.Equal(t, 3, o.Verbosity)
+}
+
 func TestOptions_Build(t *testing.T) {
+	defer ReplaceGlobal(std())()
+
 	type fields struct {
 		OutputPaths       []string
 		ErrorOutputPaths  []string
@@ -141,7 +164,7 @@ func TestOptions_Build(t *testing.T) {
 				EncodeFullCaller:  true,
 				Development:       false,
 			},
-			wantErr: assert.NoError,
+			wantErr: assert.Error,
 		},
 	}
 	for _, tt := range tests {
@@ -163,6 +186,31 @@ func TestOptions_Build(t *testing.T) {
 	}
 }
 
+func TestOptions_Build_global(t *testing.T) {
+	defer ReplaceGlobal(std())()
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	o := NewOptions()
+	o.Format = jsonFormat
+	o.OutputPaths = []string{file}
+	o.Name = "app"
+	assert.NoError(t, o.Build())
+
+	Info("global")
+	zap.L().Info("zap global")
+	Flush()
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if assert.Len(t, lines, 2) {
+		for _, line := range lines {
+			assert.Contains(t, line, `"logger":"app"`)
+			assert.Contains(t, line, `/options_test.go:`)
+		}
+	}
+}
+
 func TestOptions_String(t *testing.T) {
 	type fields struct {
 		OutputPaths       []string
@@ -194,7 +242,7 @@ func TestOptions_String(t *testing.T) {
 				EncodeFullCaller:  false,
 				Development:       false,
 			},
-			want: "{\"output-paths\":[\"stdout\"],\"error-output-paths\":[\"stderr\"],\"level\":\"info\",\"format\":\"console\",\"disable-caller\":false,\"disable-stacktrace\":false,\"enable-color\":true,\"enable-full-caller\":false,\"development\":false,\"name\":\"\"}",
+			want: "{\"output-paths\":[\"stdout\"],\"error-output-paths\":[\"stderr\"],\"level\":\"info\",\"level-overrides\":null,\"verbosity\":0,\"vmodule\":\"\",\"format\":\"console\",\"disable-caller\":false,\"disable-stacktrace\":false,\"enable-color\":true,\"enable-full-caller\":false,\"development\":false,\"name\":\"\",\"max-size\":0,\"max-age\":0,\"max-backups\":0,\"max-total-size\":0,\"compress\":\"\",\"rotate-daily\":false,\"local-time\":false,\"async\":false,\"async-buffer-size\":0,\"async-overflow\":\"\",\"async-drop-level\":\"\",\"disable-sampling\":false,\"sampling-tick\":0,\"sampling-initial\":0,\"sampling-thereafter\":0,\"sampling-exempt-level\":\"\",\"slog-default\":false,\"redact-keys\":null,\"redact-key-patterns\":null,\"redact-values\":null,\"redact-value-patterns\":null,\"redact-mask\":\"\"}",
 		},
 	}
 	for _, tt := range tests {
@@ -321,3 +369,281 @@ func TestOptions_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestOptions_Validate_rotate(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(o *Options)
+		wantPaths []string
+	}{
+		{
+			name: "size",
+			modify: func(o *Options) {
+				o.MaxSize = 100
+				o.MaxAge = 7
+				o.MaxBackups = 3
+				o.Compress = compressGzip
+			},
+		},
+		{
+			name: "daily",
+			modify: func(o *Options) {
+				o.RotateDaily = true
+				o.MaxTotalSize = 1024
+			},
+		},
+		{
+			name: "retention without rotation",
+			modify: func(o *Options) {
+				o.MaxAge = 7
+				o.MaxBackups = 3
+				o.MaxTotalSize = 1024
+				o.Compress = compressZstd
+			},
+			wantPaths: []string{"max-age", "max-backups", "max-total-size", "compress"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			tt.modify(o)
+			var paths []string
+			for _, err := range o.validate() {
+				paths = append(paths, err.Path)
+			}
+			assert.Equal(t, tt.wantPaths, paths)
+		})
+	}
+}
+
+func TestOptions_outputPaths(t *testing.T) {
+	abs, _ := filepath.Abs("app.log")
+	tests := []struct {
+		name    string
+		maxSize int
+		paths   []string
+		want    []string
+	}{
+		{
+			name:  "rotate disabled",
+			paths: []string{"stdout", "app.log"},
+			want:  []string{"stdout", "app.log"},
+		},
+		{
+			name:    "rotate enabled",
+			maxSize: 100,
+			paths:   []string{"stdout", "stderr", "app.log", "file://" + abs, "other://foo"},
+			want: []string{
+				"stdout",
+				"stderr",
+				"rotate://" + abs + "?compress=&local-time=false&max-age=0&max-backups=0&max-size=100&max-total-size=0&rotate-daily=false",
+				"rotate://" + abs + "?compress=&local-time=false&max-age=0&max-backups=0&max-size=100&max-total-size=0&rotate-daily=false",
+				"other://foo",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			o.OutputPaths = tt.paths
+			o.MaxSize = tt.maxSize
+			assert.Equal(t, tt.want, o.outputPaths())
+		})
+	}
+}
+
+func TestOptions_Validate_levelOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		wantErrs  int
+	}{
+		{
+			name:      "valid",
+			overrides: map[string]string{"db": "debug"},
+		},
+		{
+			name:      "invalid",
+			overrides: map[string]string{"db": "verbose"},
+			wantErrs:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			o.LevelOverrides = tt.overrides
+			assert.Len(t, o.Validate(), tt.wantErrs)
+		})
+	}
+}
+
+func TestOptions_Validate_sampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(o *Options)
+		wantErrs int
+	}{
+		{
+			name:   "default",
+			modify: func(o *Options) {},
+		},
+		{
+			name: "negative",
+			modify: func(o *Options) {
+				o.SamplingThereafter = -1
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "all negative",
+			modify: func(o *Options) {
+				o.SamplingTick = -1
+				o.SamplingInitial = -1
+				o.SamplingThereafter = -1
+			},
+			wantErrs: 3,
+		},
+		{
+			name: "invalid exempt level",
+			modify: func(o *Options) {
+				o.SamplingExemptLevel = "illegal"
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "disabled",
+			modify: func(o *Options) {
+				o.DisableSampling = true
+				o.SamplingExemptLevel = "illegal"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			tt.modify(o)
+			assert.Len(t, o.Validate(), tt.wantErrs)
+		})
+	}
+}
+
+func TestOptions_Validate_async(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(o *Options)
+		wantErrs int
+	}{
+		{
+			name: "default",
+			modify: func(o *Options) {
+				o.Async = true
+			},
+		},
+		{
+			name: "invalid overflow",
+			modify: func(o *Options) {
+				o.AsyncOverflow = "drop-all"
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "invalid drop level",
+			modify: func(o *Options) {
+				o.AsyncBufferSize = -1
+				o.AsyncDropLevel = "illegal"
+			},
+			wantErrs: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			tt.modify(o)
+			assert.Len(t, o.Validate(), tt.wantErrs)
+		})
+	}
+}
+
+func TestOptions_Validate_redact(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(o *Options)
+		wantErrs int
+	}{
+		{
+			name: "valid",
+			modify: func(o *Options) {
+				o.RedactKeyPatterns = []string{"^x-.*"}
+				o.RedactValues = []string{RedactJWT, RedactCreditCard, RedactEmail}
+				o.RedactMask = RedactMaskPartial
+			},
+		},
+		{
+			name: "invalid value",
+			modify: func(o *Options) {
+				o.RedactValues = []string{"phone"}
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "invalid pattern",
+			modify: func(o *Options) {
+				o.RedactValuePatterns = []string{"("}
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "invalid mask",
+			modify: func(o *Options) {
+				o.RedactMask = "stars"
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "all invalid",
+			modify: func(o *Options) {
+				o.RedactKeyPatterns = []string{"[", "^x-.*"}
+				o.RedactValues = []string{"phone", RedactJWT, "address"}
+				o.RedactValuePatterns = []string{"("}
+				o.RedactMask = "stars"
+			},
+			wantErrs: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			tt.modify(o)
+			assert.Len(t, o.Validate(), tt.wantErrs)
+		})
+	}
+}
+
+func TestOptions_buildConfig_closesErrorOutput(t *testing.T) {
+	if _, err := os.ReadDir("/proc/self/fd"); err != nil {
+		t.Skip("/proc/self/fd is not available")
+	}
+	countFDs := func() int {
+		entries, _ := os.ReadDir("/proc/self/fd")
+		return len(entries)
+	}
+
+	dir := t.TempDir()
+	for _, async := range []bool{false, true} {
+		opts := NewOptions()
+		opts.Async = async
+		opts.OutputPaths = []string{filepath.Join(dir, "app.log")}
+		opts.ErrorOutputPaths = []string{filepath.Join(dir, "error.log")}
+
+		before := countFDs()
+		for i := 0; i < 10; i++ {
+			l, err := NewWithError(opts)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, l.(*zapLogger).Close())
+		}
+		// 之前的测试留下的连接可能在此期间关闭，只检查没有增加
+		assert.LessOrEqual(t, countFDs(), before, "async=%v", async)
+	}
+}
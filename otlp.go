@@ -0,0 +1,847 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/http2"
+)
+
+const (
+	otlpGRPCScheme = "otlp+grpc"
+	otlpHTTPScheme = "otlp+http"
+
+	defaultOTLPBatchSize     = 512
+	defaultOTLPQueueSize     = 2048
+	defaultOTLPFlushInterval = time.Second
+	defaultOTLPTimeout       = 10 * time.Second
+	defaultOTLPMaxRetries    = 5
+	defaultOTLPRetryBackoff  = time.Second
+	maxOTLPRetryBackoff      = 30 * time.Second
+	// otlpWriteSyncTimeout 写入高于 Error 级别的日志时最多等待导出的时间
+	otlpWriteSyncTimeout = time.Second
+
+	otlpHTTPPath   = "/v1/logs"
+	otlpGRPCMethod = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+	otlpScopeName  = "github.com/eachinchung/log"
+)
+
+// OTLP LogRecord 中记录 logger 名称、调用位置和堆栈的属性。
+const (
+	otlpLoggerKey     = "log.logger"
+	otlpFileKey       = "code.filepath"
+	otlpLineKey       = "code.lineno"
+	otlpFunctionKey   = "code.function"
+	otlpStacktraceKey = "code.stacktrace"
+)
+
+// ContextExtractor 添加的链路信息字段，写入 LogRecord 的 trace_id、span_id 和 flags，而不是作为属性。
+const (
+	otlpTraceIDKey    = "trace_id"
+	otlpSpanIDKey     = "span_id"
+	otlpTraceFlagsKey = "trace_flags"
+)
+
+// otlpDropped 记录所有 logger 因队列已满或导出失败而丢弃的 OTLP 日志条数。
+var otlpDropped uint64
+
+// OTLPDropped 返回自进程启动以来因队列已满或重试后仍导出失败而丢弃的 OTLP 日志条数。
+func OTLPDropped() uint64 {
+	return atomic.LoadUint64(&otlpDropped)
+}
+
+// otlpConfig OTLP 输出的配置。
+type otlpConfig struct {
+	scheme        string
+	endpoint      string
+	batchSize     int
+	queueSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+	tls           bool
+	caFile        string
+	headers       map[string]string
+}
+
+// isOTLPPath 返回 path 是否是 OTLP 输出。
+func isOTLPPath(path string) bool {
+	u, err := url.Parse(path)
+
+	return err == nil && (u.Scheme == otlpGRPCScheme || u.Scheme == otlpHTTPScheme)
+}
+
+// splitOTLPPaths 将输出路径分为交给 zap.Open 的路径和 OTLP 输出。
+func splitOTLPPaths(paths []string) (sinks, otlp []string) {
+	for _, path := range paths {
+		if isOTLPPath(path) {
+			otlp = append(otlp, path)
+		} else {
+			sinks = append(sinks, path)
+		}
+	}
+
+	return sinks, otlp
+}
+
+// parseOTLPURL 解析形如 otlp+grpc://host:4317?batch-size=512 或 otlp+http://host:4318/v1/logs 的 OTLP 输出。
+// otlp+http 没有指定路径时使用 /v1/logs。
+//
+// 默认不加密，tls=true 时使用 TLS 连接并用系统证书校验服务端，ca-file 指定校验服务端的 CA 证书文件，
+// 设置 ca-file 时也会使用 TLS。header 可以重复，格式为 名称:值，如 header=Authorization:Bearer%20token，
+// 添加到每个导出请求中。
+func parseOTLPURL(path string) (otlpConfig, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return otlpConfig{}, err
+	}
+	if u.Host == "" {
+		return otlpConfig{}, fmt.Errorf("otlp URLs must contain a host: got %v", path)
+	}
+
+	cfg := otlpConfig{
+		scheme:        u.Scheme,
+		batchSize:     defaultOTLPBatchSize,
+		queueSize:     defaultOTLPQueueSize,
+		flushInterval: defaultOTLPFlushInterval,
+		timeout:       defaultOTLPTimeout,
+		maxRetries:    defaultOTLPMaxRetries,
+		retryBackoff:  defaultOTLPRetryBackoff,
+	}
+	for key, values := range u.Query() {
+		if len(values) == 0 {
+			continue
+		}
+		value := values[len(values)-1]
+		var err error
+		switch key {
+		case "header":
+			cfg.headers, value, err = parseOTLPHeaders(values)
+		case "tls":
+			cfg.tls, err = strconv.ParseBool(value)
+		case "ca-file":
+			cfg.caFile = value
+		case "batch-size":
+			cfg.batchSize, err = parsePositive(value)
+		case "queue-size":
+			cfg.queueSize, err = parsePositive(value)
+		case "flush-interval":
+			cfg.flushInterval, err = parsePositiveDuration(value)
+		case "timeout":
+			cfg.timeout, err = parsePositiveDuration(value)
+		case "max-retries":
+			cfg.maxRetries, err = strconv.Atoi(value)
+			if err == nil && cfg.maxRetries < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "retry-backoff":
+			cfg.retryBackoff, err = parsePositiveDuration(value)
+		default:
+			err = fmt.Errorf("unknown parameter")
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("invalid otlp parameter %s=%q: %v", key, value, err)
+		}
+	}
+	if cfg.caFile != "" {
+		cfg.tls = true
+	}
+
+	endpoint := url.URL{Scheme: "http", Host: u.Host, Path: u.Path}
+	if cfg.tls {
+		endpoint.Scheme = "https"
+	}
+	switch {
+	case u.Scheme == otlpGRPCScheme:
+		endpoint.Path = otlpGRPCMethod
+	case u.Path == "" || u.Path == "/":
+		endpoint.Path = otlpHTTPPath
+	}
+	cfg.endpoint = endpoint.String()
+
+	return cfg, nil
+}
+
+// parseOTLPHeaders 解析 名称:值 格式的请求头，出错时同时返回出错的值。
+func parseOTLPHeaders(values []string) (map[string]string, string, error) {
+	headers := make(map[string]string, len(values))
+	for _, value := range values {
+		name, v, ok := strings.Cut(value, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, value, fmt.Errorf("must be in the form name:value")
+		}
+		headers[http.CanonicalHeaderKey(name)] = strings.TrimSpace(v)
+	}
+
+	return headers, "", nil
+}
+
+// tlsConfig 返回连接 collector 使用的 TLS 配置，不使用 TLS 时返回 nil。
+func (cfg otlpConfig) tlsConfig() (*tls.Config, error) {
+	if !cfg.tls {
+		return nil, nil
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.caFile != "" {
+		pem, err := os.ReadFile(cfg.caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("otlp ca-file %s contains no certificates", cfg.caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err == nil && n <= 0 {
+		err = fmt.Errorf("must be positive")
+	}
+
+	return n, err
+}
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("must be positive")
+	}
+
+	return d, err
+}
+
+// newOTLPCore 创建将日志导出到 paths 中所有 OTLP 输出的 Core，返回的 closer 导出队列中剩余的日志并停止后台 goroutine。
+// 导出失败的错误写入 errSink，errSink 由调用方关闭。
+func (o *Options) newOTLPCore(paths []string, enab zapcore.LevelEnabler, errSink zapcore.WriteSyncer) (zapcore.Core, func() error, error) {
+	clients := make([]otlpClient, 0, len(paths))
+	cfgs := make([]otlpConfig, 0, len(paths))
+	for _, path := range paths {
+		var client otlpClient
+		cfg, err := parseOTLPURL(path)
+		if err == nil {
+			client, err = newOTLPClient(cfg)
+		}
+		if err != nil {
+			for _, c := range clients {
+				c.close()
+			}
+			return nil, nil, err
+		}
+		cfgs = append(cfgs, cfg)
+		clients = append(clients, client)
+	}
+
+	resource := otlpResource(o.Name)
+	exporters := make([]*otlpExporter, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		exporters = append(exporters, newOTLPExporter(cfg, clients[i], resource, errSink))
+	}
+	closer := func() error {
+		var err error
+		for _, e := range exporters {
+			if closeErr := e.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		return err
+	}
+
+	return &otlpCore{LevelEnabler: enab, exporters: exporters}, closer, nil
+}
+
+// otlpResource 编码 Resource 消息，service.name 为 name，name 为空时按照 OpenTelemetry 的约定使用
+// unknown_service:<进程名>。
+func otlpResource(name string) []byte {
+	if name == "" {
+		name = "unknown_service:" + filepath.Base(os.Args[0])
+	}
+
+	return appendAttributes(nil, 1, map[string]interface{}{"service.name": name})
+}
+
+// otlpCore 将日志编码为 OTLP LogRecord，放入每个 otlpExporter 的队列。
+type otlpCore struct {
+	zapcore.LevelEnabler
+	fields    []zapcore.Field
+	exporters []*otlpExporter
+}
+
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+
+	return &otlpCore{LevelEnabler: c.LevelEnabler, fields: append(all, fields...), exporters: c.exporters}
+}
+
+func (c *otlpCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+// Write 将日志放入队列，与 zap 的 ioCore 相同，高于 Error 级别的日志写入后立即导出。
+// 立即导出最多阻塞 otlpWriteSyncTimeout，collector 不可用时不会等待整个导出超时，之后导出在后台继续，
+// 因此 Fatal 日志在进程退出前可能来不及导出。
+func (c *otlpCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	record := encodeLogRecord(ent, c.fields, fields)
+	for _, e := range c.exporters {
+		e.enqueue(record)
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		return c.syncWithin(otlpWriteSyncTimeout)
+	}
+
+	return nil
+}
+
+// syncWithin 与 Sync 相同，但最多等待 d，超时后导出在后台继续并返回错误。
+func (c *otlpCore) syncWithin(d time.Duration) error {
+	result := make(chan error, 1)
+	go func() { result <- c.Sync() }()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("otlp export did not finish within %v", d)
+	}
+}
+
+// Sync 导出所有队列中的日志。
+func (c *otlpCore) Sync() error {
+	var err error
+	for _, e := range c.exporters {
+		if syncErr := e.Sync(); err == nil {
+			err = syncErr
+		}
+	}
+
+	return err
+}
+
+// otlpSeverity 返回级别对应的 OTLP SeverityNumber。
+func otlpSeverity(level zapcore.Level) uint64 {
+	switch level {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	case zapcore.DPanicLevel:
+		return 18
+	case zapcore.PanicLevel:
+		return 19
+	case zapcore.FatalLevel:
+		return 21
+	}
+	if level < zapcore.DebugLevel {
+		return 1
+	}
+
+	return 0
+}
+
+// encodeLogRecord 将日志编码为 LogRecord 消息，字段作为属性，logger 名称、调用位置和堆栈也记录为属性。
+func encodeLogRecord(ent zapcore.Entry, fieldSets ...[]zapcore.Field) []byte {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, fields := range fieldSets {
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+	}
+	attrs := enc.Fields
+	if ent.LoggerName != "" {
+		attrs[otlpLoggerKey] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		attrs[otlpFileKey] = ent.Caller.File
+		attrs[otlpLineKey] = int64(ent.Caller.Line)
+		if ent.Caller.Function != "" {
+			attrs[otlpFunctionKey] = ent.Caller.Function
+		}
+	}
+	if ent.Stack != "" {
+		attrs[otlpStacktraceKey] = ent.Stack
+	}
+	traceID := takeHexAttr(attrs, otlpTraceIDKey, 16)
+	spanID := takeHexAttr(attrs, otlpSpanIDKey, 8)
+	traceFlags := takeHexAttr(attrs, otlpTraceFlagsKey, 1)
+
+	b := appendFixed64Field(nil, 1, uint64(ent.Time.UnixNano()))
+	b = appendVarintField(b, 2, otlpSeverity(ent.Level))
+	b = appendStringField(b, 3, ent.Level.CapitalString())
+	b = appendBytesField(b, 5, appendAnyValue(nil, ent.Message))
+	b = appendAttributes(b, 6, attrs)
+	if traceFlags != nil {
+		b = appendFixed32Field(b, 8, uint32(traceFlags[0]))
+	}
+	if traceID != nil {
+		b = appendBytesField(b, 9, traceID)
+	}
+	if spanID != nil {
+		b = appendBytesField(b, 10, spanID)
+	}
+	b = appendFixed64Field(b, 11, uint64(time.Now().UnixNano()))
+
+	return b
+}
+
+// takeHexAttr 在 attrs[key] 是长度为 size 字节的十六进制字符串时将其从 attrs 中删除并返回解码后的值。
+func takeHexAttr(attrs map[string]interface{}, key string, size int) []byte {
+	s, ok := attrs[key].(string)
+	if !ok || len(s) != size*2 {
+		return nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	delete(attrs, key)
+
+	return b
+}
+
+// errOTLPInterrupted 后台导出的重试等待被 Sync 或 Close 打断，这批日志留给 Sync 或 Close 导出。
+var errOTLPInterrupted = errors.New("otlp export interrupted")
+
+// otlpExporter 将 LogRecord 放入有界队列，由后台 goroutine 按批导出，导出失败时按指数退避重试。
+// 队列已满或重试后仍然失败的日志被丢弃，计入 OTLPDropped。
+//
+// Sync 和 Close 最多等待 timeout：后台导出正在等待重试时会被打断，剩余的日志在 timeout 内导出，
+// 超时后 Sync 未导出的日志留在队列中，Close 未导出的日志被丢弃。
+type otlpExporter struct {
+	cfg       otlpConfig
+	client    otlpClient
+	resource  []byte
+	errOutput zapcore.WriteSyncer
+
+	mu       sync.RWMutex
+	closed   bool
+	queue    chan []byte
+	flush    chan chan error
+	flushing int32
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newOTLPExporter(cfg otlpConfig, client otlpClient, resource []byte, errOutput zapcore.WriteSyncer) *otlpExporter {
+	e := &otlpExporter{
+		cfg:       cfg,
+		client:    client,
+		resource:  resource,
+		errOutput: errOutput,
+		queue:     make(chan []byte, cfg.queueSize),
+		flush:     make(chan chan error),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go e.run()
+
+	return e
+}
+
+// enqueue 将 record 放入队列，队列已满或 exporter 已关闭时丢弃。
+func (e *otlpExporter) enqueue(record []byte) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		atomic.AddUint64(&otlpDropped, 1)
+		return
+	}
+	select {
+	case e.queue <- record:
+	default:
+		atomic.AddUint64(&otlpDropped, 1)
+	}
+}
+
+func (e *otlpExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.cfg.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, e.cfg.batchSize)
+	// send 导出 batch，重试等待被打断时保留 batch
+	send := func(ctx context.Context, background bool) error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := e.export(ctx, batch, background)
+		if err == errOTLPInterrupted {
+			return nil
+		}
+		batch = batch[:0]
+
+		return err
+	}
+	// drain 在 timeout 内导出队列中的日志，返回最后一次失败的错误
+	drain := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), e.cfg.timeout)
+		defer cancel()
+
+		var err error
+		for {
+			empty := false
+			for !empty && len(batch) < e.cfg.batchSize {
+				select {
+				case record := <-e.queue:
+					batch = append(batch, record)
+				default:
+					empty = true
+				}
+			}
+			if sendErr := send(ctx, false); sendErr != nil {
+				err = sendErr
+			}
+			if empty || ctx.Err() != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) >= e.cfg.batchSize {
+				_ = send(context.Background(), true)
+			}
+		case <-ticker.C:
+			_ = send(context.Background(), true)
+		case result := <-e.flush:
+			// 丢弃已经不需要的唤醒信号
+			select {
+			case <-e.wake:
+			default:
+			}
+			result <- drain()
+		case <-e.stop:
+			_ = drain()
+			var dropped uint64
+			for len(e.queue) > 0 {
+				<-e.queue
+				dropped++
+			}
+			atomic.AddUint64(&otlpDropped, dropped)
+			return
+		}
+	}
+}
+
+// export 导出一批日志，可重试的错误按指数退避重试，服务端指定了重试时间时按其等待，ctx 结束后不再重试。
+// 后台导出的重试等待被 Sync 或 Close 打断时返回 errOTLPInterrupted，其余情况下导出失败的日志被丢弃。
+func (e *otlpExporter) export(ctx context.Context, batch [][]byte, background bool) error {
+	body := e.request(batch)
+	backoff := e.cfg.retryBackoff
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, e.cfg.timeout)
+		err := e.client.export(attemptCtx, body)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		var retry *otlpRetryableError
+		if errors.As(err, &retry) && attempt < e.cfg.maxRetries {
+			delay := backoff
+			if retry.after > 0 {
+				delay = retry.after
+			}
+			if backoff *= 2; backoff > maxOTLPRetryBackoff {
+				backoff = maxOTLPRetryBackoff
+			}
+			if e.sleep(ctx, delay, background) {
+				continue
+			}
+			if background {
+				return errOTLPInterrupted
+			}
+		}
+
+		atomic.AddUint64(&otlpDropped, uint64(len(batch)))
+		fmt.Fprintf(e.errOutput, "%v otlp export error: %v\n", time.Now(), err)
+		_ = e.errOutput.Sync()
+
+		return err
+	}
+}
+
+// sleep 等待 d，ctx 结束或 exporter 关闭时立即返回 false。background 为 true 时 Sync 也会打断等待。
+func (e *otlpExporter) sleep(ctx context.Context, d time.Duration, background bool) bool {
+	var wake chan struct{}
+	if background {
+		if atomic.LoadInt32(&e.flushing) > 0 {
+			return false
+		}
+		wake = e.wake
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-e.stop:
+		return false
+	case <-wake:
+		return false
+	}
+}
+
+// request 编码包含 batch 中所有 LogRecord 的 ExportLogsServiceRequest 消息。
+func (e *otlpExporter) request(batch [][]byte) []byte {
+	scope := appendBytesField(nil, 1, appendStringField(nil, 1, otlpScopeName))
+	for _, record := range batch {
+		scope = appendBytesField(scope, 2, record)
+	}
+	resourceLogs := appendBytesField(nil, 1, e.resource)
+	resourceLogs = appendBytesField(resourceLogs, 2, scope)
+
+	return appendBytesField(nil, 1, resourceLogs)
+}
+
+// Sync 在 timeout 内导出队列中的日志，返回导出失败的错误。
+func (e *otlpExporter) Sync() error {
+	atomic.AddInt32(&e.flushing, 1)
+	defer atomic.AddInt32(&e.flushing, -1)
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+
+	result := make(chan error, 1)
+	select {
+	case e.flush <- result:
+	case <-e.done:
+		return nil
+	}
+
+	return <-result
+}
+
+// Close 在 timeout 内导出队列中剩余的日志并停止后台 goroutine，未导出的日志被丢弃，可以重复调用。
+func (e *otlpExporter) Close() error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.stop)
+	}
+	e.mu.Unlock()
+
+	<-e.done
+	e.client.close()
+
+	return nil
+}
+
+// otlpRetryableError 可以重试的导出错误，after 为服务端要求的重试等待时间。
+type otlpRetryableError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *otlpRetryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *otlpRetryableError) Unwrap() error {
+	return e.err
+}
+
+// otlpClient 将编码后的 ExportLogsServiceRequest 发送给 collector。
+type otlpClient interface {
+	export(ctx context.Context, body []byte) error
+	close()
+}
+
+func newOTLPClient(cfg otlpConfig) (otlpClient, error) {
+	tlsCfg, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.scheme == otlpGRPCScheme {
+		transport := &http2.Transport{TLSClientConfig: tlsCfg}
+		if tlsCfg == nil {
+			// 不使用 TLS 时 gRPC 使用不加密的 HTTP/2 (h2c)
+			transport.AllowHTTP = true
+			transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			}
+		}
+
+		return &otlpGRPCClient{endpoint: cfg.endpoint, headers: cfg.headers, client: &http.Client{Transport: transport}}, nil
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsCfg}
+
+	return &otlpHTTPClient{endpoint: cfg.endpoint, headers: cfg.headers, client: &http.Client{Transport: transport}}, nil
+}
+
+// setHeaders 将 headers 添加到请求中，Content-Type 等协议需要的请求头在之后设置，不会被覆盖。
+func setHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// retryableStatus 返回 HTTP 状态码是否可以重试。
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// statusError 返回 HTTP 状态码对应的错误，可以重试时按 Retry-After 设置等待时间。
+func statusError(resp *http.Response, format string) error {
+	err := fmt.Errorf(format, resp.Status)
+	if !retryableStatus(resp.StatusCode) {
+		return err
+	}
+	retry := &otlpRetryableError{err: err}
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		retry.after = time.Duration(seconds) * time.Second
+	}
+
+	return retry
+}
+
+// otlpHTTPClient 使用 OTLP/HTTP 的 protobuf 编码发送日志。
+type otlpHTTPClient struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+func (c *otlpHTTPClient) export(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	setHeaders(req, c.headers)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &otlpRetryableError{err: err}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	return statusError(resp, "otlp http export: %s")
+}
+
+func (c *otlpHTTPClient) close() {
+	c.client.CloseIdleConnections()
+}
+
+// otlpGRPCClient 调用 OTLP/gRPC 的 LogsService.Export 方法发送日志。
+type otlpGRPCClient struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+func (c *otlpGRPCClient) export(ctx context.Context, body []byte) error {
+	// gRPC 消息前有 1 字节的压缩标记和 4 字节的消息长度
+	msg := make([]byte, 5+len(body))
+	binary.BigEndian.PutUint32(msg[1:5], uint32(len(body)))
+	copy(msg[5:], body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	setHeaders(req, c.headers)
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &otlpRetryableError{err: err}
+	}
+	defer resp.Body.Close()
+	// 读完响应后才能拿到 trailer
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, "otlp grpc export: %s")
+	}
+
+	status, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		// 没有响应消息时状态在 header 中
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return fmt.Errorf("otlp grpc export: invalid grpc-status %q", status)
+	}
+	if code == 0 {
+		return nil
+	}
+	if m, unescapeErr := url.PathUnescape(message); unescapeErr == nil {
+		message = m
+	}
+
+	err = fmt.Errorf("otlp grpc export: code %d: %s", code, message)
+	switch code {
+	// CANCELLED、DEADLINE_EXCEEDED、RESOURCE_EXHAUSTED、ABORTED、OUT_OF_RANGE、UNAVAILABLE 和 DATA_LOSS 可以重试
+	case 1, 4, 8, 10, 11, 14, 15:
+		return &otlpRetryableError{err: err}
+	}
+
+	return err
+}
+
+func (c *otlpGRPCClient) close() {
+	c.client.CloseIdleConnections()
+}
@@ -0,0 +1,626 @@
+package log
+
+import (
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// pbMessage 解码后的 protobuf 消息，按字段编号保存值，varint 和 fixed64 为 uint64，fixed32 为 uint32，其余为 []byte。
+type pbMessage map[int][]interface{}
+
+func decodePB(t *testing.T, b []byte) pbMessage {
+	t.Helper()
+
+	m := pbMessage{}
+	varint := func() uint64 {
+		var v uint64
+		for shift := 0; ; shift += 7 {
+			if len(b) == 0 {
+				t.Fatal("truncated varint")
+			}
+			c := b[0]
+			b = b[1:]
+			v |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return v
+			}
+		}
+	}
+	for len(b) > 0 {
+		tag := varint()
+		num := int(tag >> 3)
+		switch tag & 7 {
+		case wireVarint:
+			m[num] = append(m[num], varint())
+		case wireFixed64:
+			m[num] = append(m[num], binary.LittleEndian.Uint64(b))
+			b = b[8:]
+		case wireFixed32:
+			m[num] = append(m[num], binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		case wireBytes:
+			n := int(varint())
+			m[num] = append(m[num], b[:n])
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+	}
+
+	return m
+}
+
+func (m pbMessage) bytes(num int) []byte {
+	if len(m[num]) == 0 {
+		return nil
+	}
+
+	return m[num][0].([]byte)
+}
+
+// otlpRecord 测试中解码后的 LogRecord。
+type otlpRecord struct {
+	Resource     map[string]interface{}
+	Time         time.Time
+	Severity     uint64
+	SeverityText string
+	Body         interface{}
+	Attrs        map[string]interface{}
+	Flags        uint32
+	TraceID      []byte
+	SpanID       []byte
+}
+
+func decodeAnyValue(t *testing.T, b []byte) interface{} {
+	m := decodePB(t, b)
+	for num, values := range m {
+		v := values[0]
+		switch num {
+		case 1:
+			return string(v.([]byte))
+		case 2:
+			return v.(uint64) == 1
+		case 3:
+			return int64(v.(uint64))
+		case 4:
+			return math.Float64frombits(v.(uint64))
+		case 5:
+			var arr []interface{}
+			for _, elem := range decodePB(t, v.([]byte))[1] {
+				arr = append(arr, decodeAnyValue(t, elem.([]byte)))
+			}
+			return arr
+		case 6:
+			return decodeAttributes(t, decodePB(t, v.([]byte))[1])
+		case 7:
+			return v
+		}
+	}
+
+	return nil
+}
+
+func decodeAttributes(t *testing.T, kvs []interface{}) map[string]interface{} {
+	attrs := map[string]interface{}{}
+	for _, kv := range kvs {
+		m := decodePB(t, kv.([]byte))
+		attrs[string(m.bytes(1))] = decodeAnyValue(t, m.bytes(2))
+	}
+
+	return attrs
+}
+
+// decodeExportRequest 解码 ExportLogsServiceRequest 中的所有 LogRecord。
+func decodeExportRequest(t *testing.T, body []byte) []otlpRecord {
+	t.Helper()
+
+	var records []otlpRecord
+	for _, rl := range decodePB(t, body)[1] {
+		resourceLogs := decodePB(t, rl.([]byte))
+		resource := decodeAttributes(t, decodePB(t, resourceLogs.bytes(1))[1])
+		for _, sl := range resourceLogs[2] {
+			scopeLogs := decodePB(t, sl.([]byte))
+			assert.Equal(t, otlpScopeName, string(decodePB(t, scopeLogs.bytes(1)).bytes(1)))
+			for _, lr := range scopeLogs[2] {
+				m := decodePB(t, lr.([]byte))
+				r := otlpRecord{
+					Resource:     resource,
+					Time:         time.Unix(0, int64(m[1][0].(uint64))),
+					Severity:     m[2][0].(uint64),
+					SeverityText: string(m.bytes(3)),
+					Body:         decodeAnyValue(t, m.bytes(5)),
+					Attrs:        decodeAttributes(t, m[6]),
+					TraceID:      m.bytes(9),
+					SpanID:       m.bytes(10),
+				}
+				if len(m[8]) > 0 {
+					r.Flags = m[8][0].(uint32)
+				}
+				records = append(records, r)
+			}
+		}
+	}
+
+	return records
+}
+
+// otlpCollector 测试用的 collector，记录收到的日志，handle 为 nil 时返回成功。
+type otlpCollector struct {
+	mu       sync.Mutex
+	records  []otlpRecord
+	requests int
+	header   http.Header
+	handle   func(w http.ResponseWriter, requests int) bool
+}
+
+func (c *otlpCollector) receive(t *testing.T, w http.ResponseWriter, r *http.Request, body []byte) bool {
+	c.mu.Lock()
+	c.requests++
+	c.header = r.Header.Clone()
+	handle, requests := c.handle, c.requests
+	c.mu.Unlock()
+
+	if handle != nil && handle(w, requests) {
+		return false
+	}
+
+	records := decodeExportRequest(t, body)
+	c.mu.Lock()
+	c.records = append(c.records, records...)
+	c.mu.Unlock()
+
+	return true
+}
+
+func (c *otlpCollector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.requests
+}
+
+func (c *otlpCollector) lastHeader() http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.header
+}
+
+func (c *otlpCollector) all() []otlpRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]otlpRecord(nil), c.records...)
+}
+
+func newHTTPCollector(t *testing.T, c *otlpCollector) *httptest.Server {
+	s := httptest.NewServer(httpCollectorHandler(t, c))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func httpCollectorHandler(t *testing.T, c *otlpCollector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, otlpHTTPPath, r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		c.receive(t, w, r, body)
+	})
+}
+
+func newGRPCCollector(t *testing.T, c *otlpCollector) *httptest.Server {
+	s := httptest.NewServer(grpcCollectorHandler(t, c))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func grpcCollectorHandler(t *testing.T, c *otlpCollector) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		assert.Equal(t, otlpGRPCMethod, r.URL.Path)
+		assert.Equal(t, "application/grpc", r.Header.Get("Content-Type"))
+		msg, _ := io.ReadAll(r.Body)
+		if !assert.True(t, len(msg) >= 5) || !assert.Equal(t, int(binary.BigEndian.Uint32(msg[1:5])), len(msg)-5) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		if c.receive(t, w, r, msg[5:]) {
+			_, _ = w.Write([]byte{0, 0, 0, 0, 0})
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		}
+	}), &http2.Server{})
+}
+
+func newOTLPLogger(t *testing.T, url string) *zapLogger {
+	opts := NewOptions()
+	opts.Name = "api"
+	opts.Level = "debug"
+	opts.OutputPaths = []string{url}
+	opts.ErrorOutputPaths = []string{filepath.Join(t.TempDir(), "errors.log")}
+	l := New(opts)
+	t.Cleanup(func() { _ = l.Close() })
+
+	return l
+}
+
+func TestParseOTLPURL(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    otlpConfig
+		wantErr string
+	}{
+		{
+			path: "otlp+grpc://collector:4317",
+			want: otlpConfig{
+				scheme: otlpGRPCScheme, endpoint: "http://collector:4317" + otlpGRPCMethod,
+				batchSize: 512, queueSize: 2048, flushInterval: time.Second, timeout: 10 * time.Second,
+				maxRetries: 5, retryBackoff: time.Second,
+			},
+		},
+		{
+			path: "otlp+http://collector:4318?batch-size=10&queue-size=100&flush-interval=5s&timeout=1s&max-retries=0&retry-backoff=10ms",
+			want: otlpConfig{
+				scheme: otlpHTTPScheme, endpoint: "http://collector:4318/v1/logs",
+				batchSize: 10, queueSize: 100, flushInterval: 5 * time.Second, timeout: time.Second,
+				maxRetries: 0, retryBackoff: 10 * time.Millisecond,
+			},
+		},
+		{
+			path: "otlp+http://collector:4318/custom/logs",
+			want: otlpConfig{
+				scheme: otlpHTTPScheme, endpoint: "http://collector:4318/custom/logs",
+				batchSize: 512, queueSize: 2048, flushInterval: time.Second, timeout: 10 * time.Second,
+				maxRetries: 5, retryBackoff: time.Second,
+			},
+		},
+		{
+			path: "otlp+grpc://collector:4317?ca-file=/etc/ca.pem&header=Authorization:Bearer%20token&header=x-tenant:%20a",
+			want: otlpConfig{
+				scheme: otlpGRPCScheme, endpoint: "https://collector:4317" + otlpGRPCMethod,
+				batchSize: 512, queueSize: 2048, flushInterval: time.Second, timeout: 10 * time.Second,
+				maxRetries: 5, retryBackoff: time.Second,
+				tls: true, caFile: "/etc/ca.pem", headers: map[string]string{"Authorization": "Bearer token", "X-Tenant": "a"},
+			},
+		},
+		{
+			path: "otlp+http://collector:4318?tls=true",
+			want: otlpConfig{
+				scheme: otlpHTTPScheme, endpoint: "https://collector:4318/v1/logs",
+				batchSize: 512, queueSize: 2048, flushInterval: time.Second, timeout: 10 * time.Second,
+				maxRetries: 5, retryBackoff: time.Second, tls: true,
+			},
+		},
+		{path: "otlp+grpc:///path", wantErr: "must contain a host"},
+		{path: "otlp+grpc://collector:4317?tls=maybe", wantErr: "invalid otlp parameter tls"},
+		{path: "otlp+grpc://collector:4317?header=token", wantErr: `invalid otlp parameter header="token"`},
+		{path: "otlp+grpc://collector:4317?batch-size=0", wantErr: "invalid otlp parameter batch-size"},
+		{path: "otlp+grpc://collector:4317?timeout=soon", wantErr: "invalid otlp parameter timeout"},
+		{path: "otlp+grpc://collector:4317?max-retries=-1", wantErr: "invalid otlp parameter max-retries"},
+		{path: "otlp+grpc://collector:4317?insecure=true", wantErr: "unknown parameter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseOTLPURL(tt.path)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOptions_Validate_otlp(t *testing.T) {
+	opts := NewOptions()
+	opts.OutputPaths = []string{"stdout", "otlp+http://collector:4318?queue-size=-1"}
+
+	errs := opts.validate()
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "output-paths", errs[0].Path)
+		assert.Contains(t, errs[0].Err.Error(), "invalid otlp parameter queue-size")
+	}
+}
+
+func TestOTLP(t *testing.T) {
+	tests := []struct {
+		name   string
+		server func(t *testing.T, c *otlpCollector) *httptest.Server
+		scheme string
+	}{
+		{name: "http", server: newHTTPCollector, scheme: otlpHTTPScheme},
+		{name: "grpc", server: newGRPCCollector, scheme: otlpGRPCScheme},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &otlpCollector{}
+			s := tt.server(t, c)
+			l := newOTLPLogger(t, tt.scheme+"://"+s.Listener.Addr().String()+"?flush-interval=1h")
+
+			before := time.Now()
+			l.WithName("db").WithValues("conn", 1).Info("query", String("sql", "select 1"), Any("args", []int{1, 2}))
+			l.Warn("traced",
+				String("trace_id", "0102030405060708090a0b0c0d0e0f10"),
+				String("span_id", "0102030405060708"),
+				String("trace_flags", "01"),
+				Bool("ok", true), Float64("ratio", 0.5))
+			assert.Empty(t, c.all(), "records must be batched until flush")
+			l.Flush()
+
+			got := c.all()
+			if !assert.Len(t, got, 2) {
+				return
+			}
+			assert.Equal(t, map[string]interface{}{"service.name": "api"}, got[0].Resource)
+			assert.False(t, got[0].Time.Before(before.Truncate(time.Microsecond)))
+			assert.Equal(t, uint64(9), got[0].Severity)
+			assert.Equal(t, "INFO", got[0].SeverityText)
+			assert.Equal(t, "query", got[0].Body)
+			assert.Equal(t, "api.db", got[0].Attrs[otlpLoggerKey])
+			assert.Equal(t, int64(1), got[0].Attrs["conn"])
+			assert.Equal(t, "select 1", got[0].Attrs["sql"])
+			assert.Equal(t, []interface{}{int64(1), int64(2)}, got[0].Attrs["args"])
+			assert.True(t, strings.HasSuffix(got[0].Attrs[otlpFileKey].(string), "/otlp_test.go"))
+			assert.Nil(t, got[0].TraceID)
+
+			assert.Equal(t, uint64(13), got[1].Severity)
+			assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, got[1].TraceID)
+			assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, got[1].SpanID)
+			assert.Equal(t, uint32(1), got[1].Flags)
+			assert.NotContains(t, got[1].Attrs, "trace_id")
+			assert.Equal(t, true, got[1].Attrs["ok"])
+			assert.Equal(t, 0.5, got[1].Attrs["ratio"])
+		})
+	}
+}
+
+func TestOTLP_batch(t *testing.T) {
+	c := &otlpCollector{}
+	s := newHTTPCollector(t, c)
+	l := newOTLPLogger(t, "otlp+http://"+s.Listener.Addr().String()+"?batch-size=2&flush-interval=1h")
+
+	for i := 0; i < 5; i++ {
+		l.Infof("message %d", i)
+	}
+	assert.Eventually(t, func() bool { return len(c.all()) == 4 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, l.Close())
+
+	got := c.all()
+	if assert.Len(t, got, 5) {
+		for i, r := range got {
+			assert.Equal(t, fmt.Sprintf("message %d", i), r.Body)
+		}
+	}
+	assert.Equal(t, 3, c.count())
+}
+
+func TestOTLP_retry(t *testing.T) {
+	tests := []struct {
+		name   string
+		server func(t *testing.T, c *otlpCollector) *httptest.Server
+		scheme string
+		fail   func(w http.ResponseWriter)
+	}{
+		{
+			name: "http", server: newHTTPCollector, scheme: otlpHTTPScheme,
+			fail: func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+		},
+		{
+			name: "grpc", server: newGRPCCollector, scheme: otlpGRPCScheme,
+			fail: func(w http.ResponseWriter) {
+				w.Header().Set("Grpc-Status", "14")
+				w.Header().Set("Grpc-Message", "unavailable")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &otlpCollector{handle: func(w http.ResponseWriter, requests int) bool {
+				if requests <= 2 {
+					tt.fail(w)
+					return true
+				}
+				return false
+			}}
+			s := tt.server(t, c)
+			l := newOTLPLogger(t, tt.scheme+"://"+s.Listener.Addr().String()+"?flush-interval=1h&retry-backoff=1ms")
+
+			dropped := OTLPDropped()
+			l.Info("retried")
+			l.Flush()
+
+			if assert.Len(t, c.all(), 1) {
+				assert.Equal(t, "retried", c.all()[0].Body)
+			}
+			assert.Equal(t, 3, c.count())
+			assert.Equal(t, dropped, OTLPDropped())
+		})
+	}
+}
+
+func TestOTLP_permanentError(t *testing.T) {
+	c := &otlpCollector{handle: func(w http.ResponseWriter, _ int) bool {
+		w.WriteHeader(http.StatusBadRequest)
+		return true
+	}}
+	s := newHTTPCollector(t, c)
+	l := newOTLPLogger(t, "otlp+http://"+s.Listener.Addr().String()+"?flush-interval=1h&retry-backoff=1ms")
+
+	dropped := OTLPDropped()
+	l.Info("first")
+	l.Info("second")
+	l.Flush()
+
+	assert.Equal(t, 1, c.count())
+	assert.Equal(t, dropped+2, OTLPDropped())
+	errs := readFile(t, l.reloader.opts.ErrorOutputPaths[0])
+	assert.Contains(t, errs, "otlp export error: otlp http export: 400 Bad Request")
+}
+
+func TestOTLP_queueFull(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+	c := &otlpCollector{handle: func(w http.ResponseWriter, requests int) bool {
+		if requests == 1 {
+			close(received)
+			<-release
+		}
+		return false
+	}}
+	s := newHTTPCollector(t, c)
+	l := newOTLPLogger(t, "otlp+http://"+s.Listener.Addr().String()+"?batch-size=1&queue-size=1&flush-interval=1h")
+
+	dropped := OTLPDropped()
+	l.Info("exporting")
+	<-received
+	l.Info("queued")
+	l.Info("dropped")
+	assert.Equal(t, dropped+1, OTLPDropped())
+	close(release)
+	l.Flush()
+
+	var messages []interface{}
+	for _, r := range c.all() {
+		messages = append(messages, r.Body)
+	}
+	assert.Equal(t, []interface{}{"exporting", "queued"}, messages)
+}
+
+func TestOTLP_tee(t *testing.T) {
+	c := &otlpCollector{}
+	s := newHTTPCollector(t, c)
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	opts := NewOptions()
+	opts.Format = jsonFormat
+	opts.OutputPaths = []string{file, "otlp+http://" + s.Listener.Addr().String()}
+	opts.RedactKeys = []string{"password"}
+	l := New(opts)
+	l.Info("login", String("password", "secret"))
+	assert.NoError(t, l.Close())
+
+	assert.Contains(t, readFile(t, file), `"message":"login"`)
+	if got := c.all(); assert.Len(t, got, 1) {
+		assert.Equal(t, "login", got[0].Body)
+		assert.NotEqual(t, "secret", got[0].Attrs["password"])
+	}
+}
+
+func TestOTLP_tls(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(t *testing.T, c *otlpCollector) http.Handler
+		scheme  string
+	}{
+		{name: "http", handler: httpCollectorHandler, scheme: otlpHTTPScheme},
+		{name: "grpc", handler: grpcCollectorHandler, scheme: otlpGRPCScheme},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &otlpCollector{}
+			s := httptest.NewUnstartedServer(tt.handler(t, c))
+			s.EnableHTTP2 = true
+			s.StartTLS()
+			t.Cleanup(s.Close)
+
+			caFile := filepath.Join(t.TempDir(), "ca.pem")
+			ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+			assert.NoError(t, os.WriteFile(caFile, ca, 0o600))
+
+			l := newOTLPLogger(t, tt.scheme+"://"+s.Listener.Addr().String()+
+				"?flush-interval=1h&ca-file="+url.QueryEscape(caFile)+"&header=Authorization:Bearer%20token")
+			l.Info("secure")
+			l.Flush()
+
+			if got := c.all(); assert.Len(t, got, 1) {
+				assert.Equal(t, "secure", got[0].Body)
+			}
+			assert.Equal(t, "Bearer token", c.lastHeader().Get("Authorization"))
+		})
+	}
+}
+
+func TestOTLP_invalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+	opts := NewOptions()
+	opts.OutputPaths = []string{"otlp+grpc://collector:4317?ca-file=" + url.QueryEscape(caFile)}
+	_, err := NewWithError(opts)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "contains no certificates")
+	}
+}
+
+func Test_appendKeyValue(t *testing.T) {
+	prefix := []byte{0x0a, 0x00}
+	b := appendKeyValue(prefix[:len(prefix):len(prefix)], "key", "value")
+	assert.Equal(t, prefix, b[:len(prefix)])
+	assert.Equal(t, appendKeyValue(nil, "key", "value"), b[len(prefix):])
+
+	kv := decodePB(t, b[len(prefix):])
+	assert.Equal(t, "key", string(kv.bytes(1)))
+	assert.Equal(t, "value", decodeAnyValue(t, kv.bytes(2)))
+}
+
+func TestOTLP_writeSyncTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c := &otlpCollector{handle: func(w http.ResponseWriter, _ int) bool {
+		<-release
+		return false
+	}}
+	s := newHTTPCollector(t, c)
+	l := newOTLPLogger(t, "otlp+http://"+s.Listener.Addr().String()+"?timeout=1h")
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	l.zapLogger.DPanic("collector is not responding")
+	assert.Less(t, time.Since(start), otlpWriteSyncTimeout+time.Second)
+	assert.Eventually(t, func() bool { return c.count() == 1 }, 5*time.Second, time.Millisecond)
+}
+
+func TestOTLP_syncDeadline(t *testing.T) {
+	c := &otlpCollector{handle: func(w http.ResponseWriter, _ int) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	}}
+	s := newHTTPCollector(t, c)
+	l := newOTLPLogger(t, "otlp+http://"+s.Listener.Addr().String()+"?flush-interval=10ms&retry-backoff=1h&timeout=100ms")
+
+	dropped := OTLPDropped()
+	l.Info("retrying")
+	// 等待后台导出失败后进入重试等待
+	assert.Eventually(t, func() bool { return c.count() == 1 }, 5*time.Second, time.Millisecond)
+
+	start := time.Now()
+	l.Flush()
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, 2, c.count())
+	assert.Equal(t, dropped+1, OTLPDropped())
+
+	l.Info("closing")
+	start = time.Now()
+	assert.NoError(t, l.Close())
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, dropped+2, OTLPDropped())
+}
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// 以下函数按 protobuf 的二进制格式编码 OTLP 的消息，字段编号见
+// opentelemetry/proto/collector/logs/v1/logs_service.proto 及其引用的 proto 文件。
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, num int, wire int) []byte {
+	return appendVarint(b, uint64(num)<<3|uint64(wire))
+}
+
+func appendVarintField(b []byte, num int, v uint64) []byte {
+	return appendVarint(appendTag(b, num, wireVarint), v)
+}
+
+func appendFixed64Field(b []byte, num int, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+
+	return append(appendTag(b, num, wireFixed64), buf[:]...)
+}
+
+func appendFixed32Field(b []byte, num int, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+
+	return append(appendTag(b, num, wireFixed32), buf[:]...)
+}
+
+func appendBytesField(b []byte, num int, v []byte) []byte {
+	b = appendVarint(appendTag(b, num, wireBytes), uint64(len(v)))
+
+	return append(b, v...)
+}
+
+func appendStringField(b []byte, num int, v string) []byte {
+	b = appendVarint(appendTag(b, num, wireBytes), uint64(len(v)))
+
+	return append(b, v...)
+}
+
+// appendKeyValue 编码 KeyValue 消息：key = 1，value = 2。
+func appendKeyValue(b []byte, key string, value interface{}) []byte {
+	b = appendStringField(b, 1, key)
+
+	return appendBytesField(b, 2, appendAnyValue(nil, value))
+}
+
+// appendAttributes 按 key 排序后将 attrs 编码为编号为 num 的 KeyValue 列表。
+func appendAttributes(b []byte, num int, attrs map[string]interface{}) []byte {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b = appendBytesField(b, num, appendKeyValue(nil, k, attrs[k]))
+	}
+
+	return b
+}
+
+// appendAnyValue 编码 AnyValue 消息，value 为 zapcore.MapObjectEncoder 编码字段后得到的值。
+// 对象和数组分别编码为 kvlist_value 和 array_value，无法直接表示的类型编码为 JSON 或 fmt.Sprint 的字符串。
+func appendAnyValue(b []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return b
+	case string:
+		return appendStringField(b, 1, v)
+	case bool:
+		if v {
+			return appendVarintField(b, 2, 1)
+		}
+		return appendVarintField(b, 2, 0)
+	case int:
+		return appendVarintField(b, 3, uint64(int64(v)))
+	case int64:
+		return appendVarintField(b, 3, uint64(v))
+	case int32:
+		return appendVarintField(b, 3, uint64(int64(v)))
+	case int16:
+		return appendVarintField(b, 3, uint64(int64(v)))
+	case int8:
+		return appendVarintField(b, 3, uint64(int64(v)))
+	case uint32:
+		return appendVarintField(b, 3, uint64(v))
+	case uint16:
+		return appendVarintField(b, 3, uint64(v))
+	case uint8:
+		return appendVarintField(b, 3, uint64(v))
+	case uint:
+		return appendAnyValue(b, uint64(v))
+	case uintptr:
+		return appendAnyValue(b, uint64(v))
+	case uint64:
+		if v > math.MaxInt64 {
+			return appendStringField(b, 1, fmt.Sprint(v))
+		}
+		return appendVarintField(b, 3, v)
+	case float64:
+		return appendFixed64Field(b, 4, math.Float64bits(v))
+	case float32:
+		return appendFixed64Field(b, 4, math.Float64bits(float64(v)))
+	case []byte:
+		return appendBytesField(b, 7, v)
+	case time.Duration:
+		return appendStringField(b, 1, v.String())
+	case time.Time:
+		return appendStringField(b, 1, v.Format(time.RFC3339Nano))
+	case []interface{}:
+		var arr []byte
+		for _, elem := range v {
+			arr = appendBytesField(arr, 1, appendAnyValue(nil, elem))
+		}
+		return appendBytesField(b, 5, arr)
+	case map[string]interface{}:
+		return appendBytesField(b, 6, appendAttributes(nil, 1, v))
+	case fmt.Stringer:
+		return appendStringField(b, 1, v.String())
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		return appendStringField(b, 1, string(data))
+	}
+
+	return appendStringField(b, 1, fmt.Sprint(value))
+}
@@ -0,0 +1,586 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 脱敏后的值的显示方式。
+const (
+	// RedactMaskFull 将值全部替换为 ******。
+	RedactMaskFull = "full"
+	// RedactMaskPartial 保留值首尾各四分之一 (最多 4 个字符)，其余替换为 ****，过短的值全部替换。
+	RedactMaskPartial = "partial"
+	// RedactMaskHash 将值替换为 SHA-256 摘要的前 16 个十六进制字符，相同的值脱敏后仍然相同。
+	RedactMaskHash = "hash"
+
+	// 内置的按值脱敏规则。
+	RedactJWT        = "jwt"
+	RedactCreditCard = "credit-card"
+	RedactEmail      = "email"
+
+	redactedValue = "******"
+	redactTag     = "redact"
+	// maxRedactDepth 反射脱敏时最多进入的层数，更深的值直接置为零值，避免循环引用导致栈溢出。
+	maxRedactDepth = 32
+)
+
+// DefaultRedactKeys 返回常见的敏感字段名称，可以设置到 Options.RedactKeys 中开启按名称脱敏：
+//
+//	opts.RedactKeys = log.DefaultRedactKeys()
+func DefaultRedactKeys() []string {
+	return []string{
+		"password", "passwd", "secret", "token", "access-token", "refresh-token",
+		"authorization", "api-key", "private-key",
+	}
+}
+
+// valuePattern 一个按值脱敏的规则，valid 不为空时只脱敏通过校验的匹配。
+type valuePattern struct {
+	re    *regexp.Regexp
+	valid func(s string) bool
+}
+
+var builtinValuePatterns = map[string]valuePattern{
+	RedactJWT:        {re: regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+	RedactCreditCard: {re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhnValid},
+	RedactEmail:      {re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+}
+
+// luhnValid 使用 Luhn 算法校验 s 中的数字是否是一个有效的银行卡号。
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// redactor 按字段名称、值的模式以及结构体标签对日志字段脱敏。
+//
+// 按值脱敏检查字符串、[]byte、error 和 fmt.Stringer 字段，以及 ObjectMarshaler 和 ArrayMarshaler
+// 添加的字符串，不检查反射编码 (如 zap.Any 传入的结构体和 map) 中的字符串，这些值只按结构体标签脱敏。
+type redactor struct {
+	// keys 是规范化后的字段名称，数量通常很少，逐个比较不需要为每个字段规范化名称
+	keys        []string
+	keyPatterns []*regexp.Regexp
+	values      []valuePattern
+	mask        string
+}
+
+// newRedactor 根据 Options 创建 redactor，配置无效时返回包含所有错误的 *OptionsError。
+func newRedactor(o *Options) (*redactor, error) {
+	r, errs := parseRedactor(o)
+	if len(errs) > 0 {
+		optsErr := &OptionsError{}
+		for _, err := range errs {
+			optsErr.Errors = append(optsErr.Errors, err)
+		}
+
+		return nil, optsErr
+	}
+
+	return r, nil
+}
+
+// parseRedactor 根据 Options 创建 redactor，返回所有无效的脱敏配置。
+func parseRedactor(o *Options) (*redactor, []*FieldError) {
+	var errs []*FieldError
+	r := &redactor{mask: o.RedactMask}
+	for _, key := range o.RedactKeys {
+		if key = normalizeKey(key); key != "" {
+			r.keys = append(r.keys, key)
+		}
+	}
+	for _, pattern := range o.RedactKeyPatterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			errs = append(errs, &FieldError{
+				Path: "redact-key-patterns",
+				Err:  fmt.Errorf("not a valid redact key pattern: %q: %v", pattern, err),
+			})
+			continue
+		}
+		r.keyPatterns = append(r.keyPatterns, re)
+	}
+	for _, name := range o.RedactValues {
+		p, ok := builtinValuePatterns[name]
+		if !ok {
+			errs = append(errs, &FieldError{Path: "redact-values", Err: fmt.Errorf("not a valid redact value: %q", name)})
+			continue
+		}
+		r.values = append(r.values, p)
+	}
+	for _, pattern := range o.RedactValuePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, &FieldError{
+				Path: "redact-value-patterns",
+				Err:  fmt.Errorf("not a valid redact value pattern: %q: %v", pattern, err),
+			})
+			continue
+		}
+		r.values = append(r.values, valuePattern{re: re})
+	}
+	switch r.mask {
+	case "":
+		r.mask = RedactMaskFull
+	case RedactMaskFull, RedactMaskPartial, RedactMaskHash:
+	default:
+		errs = append(errs, &FieldError{Path: "redact-mask", Err: fmt.Errorf("not a valid redact mask: %q", o.RedactMask)})
+	}
+
+	return r, errs
+}
+
+// isKeySeparator 返回 r 是否是比较字段名称时忽略的分隔符。
+func isKeySeparator(r rune) bool {
+	switch r {
+	case '-', '_', '.', ' ':
+		return true
+	}
+
+	return false
+}
+
+// normalizeKey 将字段名称转换为小写并去掉分隔符，使 api-key、api_key 和 apiKey 视为相同的名称。
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if isKeySeparator(r) {
+			return -1
+		}
+
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// equalNormalizedKey 返回 key 规范化后是否等于 normalized，比较时不分配内存。
+func equalNormalizedKey(normalized, key string) bool {
+	i := 0
+	for _, c := range key {
+		if isKeySeparator(c) {
+			continue
+		}
+		if i >= len(normalized) {
+			return false
+		}
+		n, size := utf8.DecodeRuneInString(normalized[i:])
+		if n != unicode.ToLower(c) {
+			return false
+		}
+		i += size
+	}
+
+	return i == len(normalized)
+}
+
+// hasRules 返回是否设置了按名称或按值脱敏的规则。
+func (r *redactor) hasRules() bool {
+	return len(r.keys) > 0 || len(r.keyPatterns) > 0 || len(r.values) > 0
+}
+
+// wrap 返回在写入 core 之前对字段脱敏的 Core，没有设置脱敏规则时原样返回 core，
+// 此时结构体标签也不会生效。
+func (r *redactor) wrap(core zapcore.Core) zapcore.Core {
+	if !r.hasRules() {
+		return core
+	}
+
+	return &redactCore{Core: core, r: r}
+}
+
+func (r *redactor) matchKey(key string) bool {
+	for _, k := range r.keys {
+		if equalNormalizedKey(k, key) {
+			return true
+		}
+	}
+	for _, re := range r.keyPatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maskString 按 mask 方式返回 s 脱敏后的值。
+func (r *redactor) maskString(s string) string {
+	switch r.mask {
+	case RedactMaskPartial:
+		runes := []rune(s)
+		n := len(runes) / 4
+		if n > 4 {
+			n = 4
+		}
+		if n == 0 {
+			return redactedValue
+		}
+
+		return string(runes[:n]) + "****" + string(runes[len(runes)-n:])
+	case RedactMaskHash:
+		sum := sha256.Sum256([]byte(s))
+
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		return redactedValue
+	}
+}
+
+// redactString 对 s 中匹配按值脱敏规则的部分脱敏。
+func (r *redactor) redactString(s string) (string, bool) {
+	redacted := false
+	for _, p := range r.values {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.valid != nil && !p.valid(match) {
+				return match
+			}
+			redacted = true
+
+			return r.maskString(match)
+		})
+	}
+
+	return s, redacted
+}
+
+// redactFields 返回脱敏后的字段，没有字段需要脱敏时直接返回 fields。
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, f := range fields {
+		if rf, ok := r.redactField(f); ok {
+			if redacted == nil {
+				redacted = append([]zapcore.Field(nil), fields...)
+			}
+			redacted[i] = rf
+		}
+	}
+	if redacted == nil {
+		return fields
+	}
+
+	return redacted
+}
+
+func (r *redactor) redactField(f zapcore.Field) (zapcore.Field, bool) {
+	switch f.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return f, false
+	}
+
+	if r.matchKey(f.Key) {
+		if r.mask == RedactMaskFull {
+			return zap.String(f.Key, redactedValue), true
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+
+		return zap.String(f.Key, r.maskString(fmt.Sprint(enc.Fields[f.Key]))), true
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		if s, ok := r.redactString(f.String); ok {
+			f.String = s
+			return f, true
+		}
+	case zapcore.ByteStringType:
+		if s, ok := r.redactString(string(f.Interface.([]byte))); ok {
+			return zap.ByteString(f.Key, []byte(s)), true
+		}
+	case zapcore.ErrorType, zapcore.StringerType:
+		// 按编码后的字符串脱敏，脱敏后替换为字符串字段，error 的详细信息 (errorVerbose) 不再输出
+		if len(r.values) == 0 {
+			break
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		if s, ok := enc.Fields[f.Key].(string); ok {
+			if s, ok = r.redactString(s); ok {
+				return zap.String(f.Key, s), true
+			}
+		}
+	case zapcore.ReflectType:
+		if v, ok := r.redactReflected(f.Interface); ok {
+			f.Interface = v
+			return f, true
+		}
+	case zapcore.ObjectMarshalerType:
+		if r.hasRules() {
+			f.Interface = redactObject{ObjectMarshaler: f.Interface.(zapcore.ObjectMarshaler), r: r}
+			return f, true
+		}
+	case zapcore.ArrayMarshalerType:
+		if r.hasRules() {
+			f.Interface = redactArray{ArrayMarshaler: f.Interface.(zapcore.ArrayMarshaler), r: r}
+			return f, true
+		}
+	}
+
+	return f, false
+}
+
+// redactReflected 返回 v 的副本，其中带有 `log:"redact"` 标签的字段已经脱敏。
+// v 中没有这样的字段时返回 false，不会复制。
+func (r *redactor) redactReflected(v interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !hasRedactTag(rv.Type()) {
+		return v, false
+	}
+
+	return r.redactValue(rv, 0).Interface(), true
+}
+
+func (r *redactor) redactValue(rv reflect.Value, depth int) reflect.Value {
+	t := rv.Type()
+	if !hasRedactTag(t) {
+		return rv
+	}
+	if depth > maxRedactDepth {
+		return reflect.Zero(t)
+	}
+	depth++
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.New(t.Elem())
+		cp.Elem().Set(r.redactValue(rv.Elem(), depth))
+
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(t).Elem()
+		cp.Set(rv)
+		for i := 0; i < t.NumField(); i++ {
+			field := cp.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			if t.Field(i).Tag.Get("log") == redactTag {
+				if field.Kind() == reflect.String {
+					field.SetString(r.maskString(field.String()))
+				} else {
+					field.Set(reflect.Zero(field.Type()))
+				}
+				continue
+			}
+			field.Set(r.redactValue(field, depth))
+		}
+
+		return cp
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.MakeSlice(t, rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(r.redactValue(rv.Index(i), depth))
+		}
+
+		return cp
+	case reflect.Array:
+		cp := reflect.New(t).Elem()
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(r.redactValue(rv.Index(i), depth))
+		}
+
+		return cp
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.MakeMapWithSize(t, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), r.redactValue(iter.Value(), depth))
+		}
+
+		return cp
+	}
+
+	return rv
+}
+
+// redactTypes 缓存每个类型是否包含带有 `log:"redact"` 标签的字段。
+var redactTypes sync.Map
+
+// hasRedactTag 返回 t 或 t 包含的类型中是否有带有 `log:"redact"` 标签的字段。
+func hasRedactTag(t reflect.Type) bool {
+	if v, ok := redactTypes.Load(t); ok {
+		return v.(bool)
+	}
+
+	has := searchRedactTag(t, make(map[reflect.Type]struct{}))
+	redactTypes.Store(t, has)
+
+	return has
+}
+
+// searchRedactTag 查找 t 包含的类型中带有标签的字段，visited 记录已经访问过的类型，避免递归类型无限循环。
+// 找到标签的结果是确定的，可以缓存；没有找到时可能是因为环上的类型还未查找完，只由 hasRedactTag 缓存最终结果。
+func searchRedactTag(t reflect.Type, visited map[reflect.Type]struct{}) bool {
+	if v, ok := redactTypes.Load(t); ok {
+		return v.(bool)
+	}
+	if _, ok := visited[t]; ok {
+		return false
+	}
+	visited[t] = struct{}{}
+
+	has := false
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		has = searchRedactTag(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !has; i++ {
+			f := t.Field(i)
+			has = f.IsExported() && (f.Tag.Get("log") == redactTag || searchRedactTag(f.Type, visited))
+		}
+	}
+	if has {
+		redactTypes.Store(t, true)
+	}
+
+	return has
+}
+
+// redactObject 在编码时对 ObjectMarshaler 添加的字段脱敏。
+type redactObject struct {
+	zapcore.ObjectMarshaler
+	r *redactor
+}
+
+func (o redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(&redactEncoder{ObjectEncoder: enc, r: o.r})
+}
+
+// redactEncoder 对字符串、对象以及反射编码的字段脱敏。
+type redactEncoder struct {
+	zapcore.ObjectEncoder
+	r *redactor
+}
+
+func (e *redactEncoder) AddString(key, value string) {
+	if e.r.matchKey(key) {
+		value = e.r.maskString(value)
+	} else {
+		value, _ = e.r.redactString(value)
+	}
+	e.ObjectEncoder.AddString(key, value)
+}
+
+func (e *redactEncoder) AddByteString(key string, value []byte) {
+	e.AddString(key, string(value))
+}
+
+func (e *redactEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	if e.r.matchKey(key) {
+		e.ObjectEncoder.AddString(key, redactedValue)
+		return nil
+	}
+
+	return e.ObjectEncoder.AddObject(key, redactObject{ObjectMarshaler: marshaler, r: e.r})
+}
+
+func (e *redactEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	if e.r.matchKey(key) {
+		e.ObjectEncoder.AddString(key, redactedValue)
+		return nil
+	}
+
+	return e.ObjectEncoder.AddArray(key, redactArray{ArrayMarshaler: marshaler, r: e.r})
+}
+
+func (e *redactEncoder) AddReflected(key string, value interface{}) error {
+	if e.r.matchKey(key) {
+		e.ObjectEncoder.AddString(key, redactedValue)
+		return nil
+	}
+	value, _ = e.r.redactReflected(value)
+
+	return e.ObjectEncoder.AddReflected(key, value)
+}
+
+// redactArray 在编码时对 ArrayMarshaler 添加的元素脱敏。
+type redactArray struct {
+	zapcore.ArrayMarshaler
+	r *redactor
+}
+
+func (a redactArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.ArrayMarshaler.MarshalLogArray(&redactArrayEncoder{ArrayEncoder: enc, r: a.r})
+}
+
+// redactArrayEncoder 对数组中的字符串、对象、数组以及反射编码的元素脱敏，数组元素没有名称，只按值脱敏。
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	r *redactor
+}
+
+func (e *redactArrayEncoder) AppendString(value string) {
+	value, _ = e.r.redactString(value)
+	e.ArrayEncoder.AppendString(value)
+}
+
+func (e *redactArrayEncoder) AppendByteString(value []byte) {
+	e.AppendString(string(value))
+}
+
+func (e *redactArrayEncoder) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactObject{ObjectMarshaler: marshaler, r: e.r})
+}
+
+func (e *redactArrayEncoder) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactArray{ArrayMarshaler: marshaler, r: e.r})
+}
+
+func (e *redactArrayEncoder) AppendReflected(value interface{}) error {
+	value, _ = e.r.redactReflected(value)
+
+	return e.ArrayEncoder.AppendReflected(value)
+}
+
+// redactCore 在写入之前对日志字段脱敏。
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.redactFields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.r.redactFields(fields))
+}
@@ -0,0 +1,374 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultWatchInterval = time.Second
+
+	// reloadGracePeriod 重新加载后等待多久关闭旧的输出，让正在写入的日志完成。
+	reloadGracePeriod = time.Second
+)
+
+// reloadState 一次构建出的 Core 以及关闭它的输出的函数。
+type reloadState struct {
+	core   zapcore.Core
+	closer func() error
+}
+
+// reloader 保存 logger 当前使用的 Core，重新加载配置时整体替换，
+// 正在记录的日志要么使用旧的 Core，要么使用新的 Core，不会看到构建了一半的 logger。
+type reloader struct {
+	mu    sync.Mutex
+	opts  *Options
+	state atomic.Value // *reloadState
+}
+
+func (r *reloader) load() *reloadState {
+	return r.state.Load().(*reloadState)
+}
+
+// wrap 作为 zap.WrapCore 的参数，记录 core 并返回跟随 reloader 变化的 Core。
+func (r *reloader) wrap(core zapcore.Core) zapcore.Core {
+	r.state.Store(&reloadState{core: core, closer: func() error { return nil }})
+
+	return &reloadCore{r: r}
+}
+
+func (r *reloader) setCloser(closer func() error) {
+	r.state.Store(&reloadState{core: r.load().core, closer: closer})
+}
+
+// close 关闭当前 Core 的输出。
+func (r *reloader) close() error {
+	return r.load().closer()
+}
+
+// reloadCore 将日志交给 reloader 当前的 Core 处理，With 添加的字段在 Core 替换后重新添加到新的 Core 上。
+type reloadCore struct {
+	r      *reloader
+	fields []zapcore.Field
+	cache  atomic.Value // *reloadCache
+}
+
+// reloadCache 在 state.core 上添加 fields 后得到的 Core。
+type reloadCache struct {
+	state *reloadState
+	core  zapcore.Core
+}
+
+func (c *reloadCore) current() zapcore.Core {
+	state := c.r.load()
+	if len(c.fields) == 0 {
+		return state.core
+	}
+	if cache, ok := c.cache.Load().(*reloadCache); ok && cache.state == state {
+		return cache.core
+	}
+
+	core := state.core.With(c.fields)
+	c.cache.Store(&reloadCache{state: state, core: core})
+
+	return core
+}
+
+func (c *reloadCore) Enabled(lvl zapcore.Level) bool {
+	return c.current().Enabled(lvl)
+}
+
+func (c *reloadCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+
+	return &reloadCore{r: c.r, fields: append(all, fields...)}
+}
+
+func (c *reloadCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.current().Check(ent, ce)
+}
+
+func (c *reloadCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(ent, fields)
+}
+
+func (c *reloadCore) Sync() error {
+	return c.current().Sync()
+}
+
+// buildCore 按照 New 的方式构建 Core，不包含日志级别的过滤。
+func (o *Options) buildCore() (*reloadState, error) {
+	var core zapcore.Core
+	_, closer, err := o.buildConfig(o.zapConfig(), o.samplingOption(), zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		core = c
+		return c
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &reloadState{core: core, closer: closer}, nil
+}
+
+// coreOptions 返回 o 中影响 Core 构建的部分，日志级别和详细级别可以直接修改，不需要重新构建 Core。
+// Name、Development、DisableCaller 和 DisableStacktrace 只在创建 logger 时生效，同样不参与比较。
+func coreOptions(o *Options) Options {
+	c := *o
+	c.Level = ""
+	c.LevelOverrides = nil
+	c.Verbosity = 0
+	c.VModule = ""
+	c.SamplingHook = nil
+	c.Name = ""
+	c.Development = false
+	c.DisableCaller = false
+	c.DisableStacktrace = false
+	c.envErrs = nil
+
+	return c
+}
+
+// reload 使用 opts 重新配置 logger：日志级别、详细级别和按名称覆盖的级别直接修改，
+// 其余影响 Core 的配置发生变化时重新构建 Core 并整体替换，旧的输出在 reloadGracePeriod 后关闭。
+// Name、Development、DisableCaller 和 DisableStacktrace 只在创建 logger 时生效。
+// opts 无效时返回错误，logger 继续使用原来的配置。
+func (l *zapLogger) reload(opts *Options) error {
+	r := l.reloader
+	if r == nil {
+		return errors.New("logger does not support reloading")
+	}
+	if errs := opts.Validate(); len(errs) > 0 {
+		return &OptionsError{Errors: errs}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := *opts
+	if next.SamplingHook == nil {
+		// SamplingHook 无法从配置文件中设置，沿用原来的设置
+		next.SamplingHook = r.opts.SamplingHook
+	}
+	if !reflect.DeepEqual(coreOptions(&next), coreOptions(r.opts)) {
+		state, err := next.buildCore()
+		if err != nil {
+			return err
+		}
+		prev := r.load()
+		state.closer = closeAfterGrace(prev.closer, state.closer)
+		r.state.Store(state)
+		_ = prev.core.Sync()
+	}
+
+	if next.Level != r.opts.Level {
+		var level zapcore.Level
+		_ = level.UnmarshalText([]byte(next.Level))
+		l.level.SetLevel(level)
+	}
+	if !reflect.DeepEqual(next.LevelOverrides, r.opts.LevelOverrides) {
+		overrides, _ := parseLevelOverrides(next.LevelOverrides)
+		l.levels.setOverrides(overrides)
+	}
+	if next.Verbosity != r.opts.Verbosity {
+		l.levels.setVerbosity(next.Verbosity)
+	}
+	if next.VModule != r.opts.VModule {
+		vm, _ := parseVModule(next.VModule)
+		l.levels.setVModule(vm)
+	}
+	r.opts = &next
+
+	return nil
+}
+
+// closeAfterGrace 在 reloadGracePeriod 后关闭旧的输出，返回的函数先关闭尚未关闭的旧输出再调用 closer。
+func closeAfterGrace(old, closer func() error) func() error {
+	var once sync.Once
+	closeOld := func() { once.Do(func() { _ = old() }) }
+	time.AfterFunc(reloadGracePeriod, closeOld)
+
+	return func() error {
+		closeOld()
+		return closer()
+	}
+}
+
+// WatchOption 设置 WatchConfig。
+type WatchOption func(w *configWatcher)
+
+// WatchInterval 设置检查配置文件是否变化的间隔，默认为 1 秒。
+func WatchInterval(d time.Duration) WatchOption {
+	return func(w *configWatcher) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WatchFlags 设置重新加载配置时使用的 flag，命令行中设置过的 flag 优先于配置文件，详见 LoadOptions。
+func WatchFlags(fs *pflag.FlagSet) WatchOption {
+	return func(w *configWatcher) { w.flags = append(w.flags, fs) }
+}
+
+// WatchEnv 设置加载配置时从前缀为 prefix 的环境变量中读取配置，环境变量优先于配置文件，详见 Options.LoadEnv。
+func WatchEnv(prefix string) WatchOption {
+	return func(w *configWatcher) { w.envPrefixes = append(w.envPrefixes, prefix) }
+}
+
+type configWatcher struct {
+	path        string
+	interval    time.Duration
+	flags       []*pflag.FlagSet
+	envPrefixes []string
+	modTime     time.Time
+	size        int64
+	stop        chan struct{}
+	done        chan struct{}
+
+	// errPaths 和 errOut 是报告重新加载错误使用的输出，只在 ErrorOutputPaths 变化时重新打开
+	errPaths []string
+	errOut   zapcore.WriteSyncer
+	closeErr func()
+}
+
+// WatchConfig 从配置文件 path 加载配置并应用到全局 logger，之后定期检查文件的修改时间和大小，
+// 文件变化时重新加载。日志级别、按名称覆盖的级别、采样、输出路径和格式等配置都可以在运行时修改，
+// 详见 LoadOptions。
+//
+// 首次加载失败时返回错误。之后重新加载失败时，错误写入当前配置的 ErrorOutputPaths，logger 继续使用原来的配置。
+// 调用返回的 stop 停止检查。
+func WatchConfig(path string, opts ...WatchOption) (stop func(), err error) {
+	w := &configWatcher{
+		path:     path,
+		interval: defaultWatchInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if _, err := w.changed(); err != nil {
+		return nil, err
+	}
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.run()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(w.stop)
+			<-w.done
+		})
+	}, nil
+}
+
+func (w *configWatcher) run() {
+	defer close(w.done)
+	defer w.closeErrorOutput()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := w.changed()
+		if err == nil && changed {
+			err = w.reload()
+		}
+		if err != nil {
+			w.reportError(err)
+		}
+	}
+}
+
+// changed 返回配置文件的修改时间或大小是否与上次检查时不同。
+func (w *configWatcher) changed() (bool, error) {
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		if w.size < 0 {
+			// 已经报告过，文件恢复前不再重复报告
+			return false, nil
+		}
+		w.modTime, w.size = time.Time{}, -1
+
+		return false, err
+	}
+	if fi.ModTime().Equal(w.modTime) && fi.Size() == w.size {
+		return false, nil
+	}
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+
+	return true, nil
+}
+
+func (w *configWatcher) reload() error {
+	opts, err := LoadOptions(w.path, w.flags...)
+	if err != nil {
+		return err
+	}
+	// LoadEnv 每次调用只保留本次的错误，汇总所有前缀的错误交给 reload 校验
+	var envErrs []*FieldError
+	for _, prefix := range w.envPrefixes {
+		opts.LoadEnv(prefix, w.flags...)
+		envErrs = append(envErrs, opts.envErrs...)
+	}
+	opts.envErrs = envErrs
+
+	return std().reload(opts)
+}
+
+// reportError 将重新加载配置的错误写入全局 logger 当前配置的 ErrorOutputPaths。
+func (w *configWatcher) reportError(err error) {
+	out := w.errorOutput()
+	fmt.Fprintf(out, "%v reload log config %s: %v\n", time.Now().UTC(), w.path, err)
+	_ = out.Sync()
+}
+
+// errorOutput 返回全局 logger 当前配置的 ErrorOutputPaths 对应的输出，路径没有变化时沿用已经打开的输出，
+// 无法打开时使用 stderr。
+func (w *configWatcher) errorOutput() zapcore.WriteSyncer {
+	paths := NewOptions().ErrorOutputPaths
+	if r := std().reloader; r != nil {
+		r.mu.Lock()
+		paths = r.opts.ErrorOutputPaths
+		r.mu.Unlock()
+	}
+	if w.errOut != nil && reflect.DeepEqual(paths, w.errPaths) {
+		return w.errOut
+	}
+
+	w.closeErrorOutput()
+	out, closeOut, err := zap.Open(paths...)
+	if err != nil {
+		out, closeOut = zapcore.Lock(os.Stderr), func() {}
+	}
+	w.errPaths, w.errOut, w.closeErr = paths, out, closeOut
+
+	return out
+}
+
+func (w *configWatcher) closeErrorOutput() {
+	if w.closeErr != nil {
+		w.closeErr()
+	}
+	w.errPaths, w.errOut, w.closeErr = nil, nil, nil
+}
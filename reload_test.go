@@ -0,0 +1,166 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func Test_zapLogger_reload(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+
+	opts := NewOptions()
+	opts.Format = jsonFormat
+	opts.OutputPaths = []string{first}
+	l := New(opts)
+	defer l.Close()
+
+	named := l.WithName("db").WithValues("conn", 1)
+	named.Info("before")
+
+	next := NewOptions()
+	next.Format = consoleFormat
+	next.EnableColor = false
+	next.OutputPaths = []string{second}
+	next.Level = "warn"
+	next.LevelOverrides = map[string]string{"db": "debug"}
+	assert.NoError(t, l.reload(next))
+
+	l.Info("dropped")
+	l.Warn("after")
+	named.Debug("derived")
+	l.Flush()
+
+	assert.Contains(t, readFile(t, first), `"message":"before","conn":1`)
+	assert.NotContains(t, readFile(t, first), "after")
+	got := readFile(t, second)
+	assert.NotContains(t, got, "dropped")
+	assert.Contains(t, got, "WARN\t")
+	assert.Contains(t, got, "after")
+	assert.Contains(t, got, "derived\t{\"conn\": 1}")
+
+	invalid := NewOptions()
+	invalid.Level = "verbose"
+	assert.Error(t, l.reload(invalid))
+	assert.Equal(t, WarnLevel, l.GetLevel())
+}
+
+func Test_zapLogger_reload_levelOnly(t *testing.T) {
+	l := New(NewOptions())
+	state := l.reloader.load()
+
+	l.SetLevel(ErrorLevel)
+	assert.NoError(t, l.reload(NewOptions()))
+	assert.Equal(t, ErrorLevel, l.GetLevel(), "unchanged level must not overwrite SetLevel")
+	assert.Same(t, state, l.reloader.load(), "core must not be rebuilt")
+
+	next := NewOptions()
+	next.Level = "debug"
+	assert.NoError(t, l.reload(next))
+	assert.Equal(t, DebugLevel, l.GetLevel())
+	assert.Same(t, state, l.reloader.load(), "core must not be rebuilt")
+
+	// 只在创建 logger 时生效的配置不会重新构建 Core
+	next = NewOptions()
+	next.Name = "svc"
+	next.Development = true
+	next.DisableCaller = true
+	next.DisableStacktrace = true
+	assert.NoError(t, l.reload(next))
+	assert.Same(t, state, l.reloader.load(), "core must not be rebuilt")
+}
+
+func Test_zapLogger_reload_unsupported(t *testing.T) {
+	l := &zapLogger{}
+	assert.Error(t, l.reload(NewOptions()))
+}
+
+func writeConfig(t *testing.T, path string, mtime time.Time, format string, args ...interface{}) {
+	t.Helper()
+
+	assert.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(format, args...)), 0o644))
+	// 保证修改时间变化，不依赖文件系统的时间精度
+	assert.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestWatchConfig(t *testing.T) {
+	defer ReplaceGlobal(New(NewOptions()))()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	errors := filepath.Join(dir, "errors.log")
+	now := time.Now()
+
+	writeConfig(t, path, now, "log:\n  format: json\n  output-paths: [%s]\n  error-output-paths: [%s]\n", first, errors)
+	stop, err := WatchConfig(path, WatchInterval(10*time.Millisecond))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stop()
+
+	Info("first")
+	Debug("dropped")
+	Flush()
+	assert.Contains(t, readFile(t, first), `"message":"first"`)
+
+	writeConfig(t, path, now.Add(time.Second),
+		"log:\n  level: debug\n  enable-color: false\n  output-paths: [%s]\n  error-output-paths: [%s]\n", second, errors)
+	assert.Eventually(t, func() bool { return GetLevel() == DebugLevel }, 5*time.Second, 10*time.Millisecond)
+
+	Debug("second")
+	Flush()
+	assert.NotContains(t, readFile(t, first), "second")
+	assert.Contains(t, readFile(t, second), "DEBUG\t")
+	assert.Contains(t, readFile(t, second), "second")
+
+	writeConfig(t, path, now.Add(2*time.Second), "log:\n  level: verbose\n")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(readFile(t, errors), "reload log config "+path)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, readFile(t, errors), `log.level: unrecognized level: "verbose"`)
+	assert.Equal(t, DebugLevel, GetLevel())
+
+	stop()
+	stop()
+}
+
+func Test_configWatcher_errorOutput(t *testing.T) {
+	w := &configWatcher{}
+	defer w.closeErrorOutput()
+
+	out := w.errorOutput()
+	assert.Same(t, out, w.errorOutput(), "error output must be opened once")
+	assert.Equal(t, NewOptions().ErrorOutputPaths, w.errPaths)
+
+	w.closeErrorOutput()
+	assert.Nil(t, w.errOut)
+}
+
+func TestWatchConfig_error(t *testing.T) {
+	dir := t.TempDir()
+	_, err := WatchConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(t, path, time.Now(), "log:\n  format: xml\n")
+	_, err = WatchConfig(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,529 @@
+package log
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	rotateScheme     = "rotate"
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	megabyte         = 1024 * 1024
+)
+
+// currentTime 返回当前时间，测试时可以替换。
+var currentTime = time.Now
+
+// rotators 记录所有已打开的滚动文件，同一个文件只会被一个 rotateWriter 持有，
+// 避免多个 Logger 输出到同一文件时各自滚动而互相覆盖。
+var rotators = struct {
+	sync.Mutex
+	m map[string]*rotateWriter
+}{m: make(map[string]*rotateWriter)}
+
+func init() {
+	if err := zap.RegisterSink(rotateScheme, newRotateSink); err != nil {
+		panic(err)
+	}
+}
+
+// rotateConfig 滚动策略。
+type rotateConfig struct {
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	maxTotal   int64
+	daily      bool
+	localTime  bool
+	compress   string
+}
+
+// millEnabled 返回是否需要压缩或清理备份文件。
+func (cfg rotateConfig) millEnabled() bool {
+	return cfg.maxBackups > 0 || cfg.maxAge > 0 || cfg.maxTotal > 0 || cfg.compress != compressNone
+}
+
+// rotateWriter 是一个按大小和时间滚动的文件 zap.Sink，可以被多个 goroutine 并发写入。
+// 滚动在持有锁的情况下完成，因此不会丢失日志。
+type rotateWriter struct {
+	mu         sync.Mutex
+	filename   string
+	cfg        rotateConfig
+	file       *os.File
+	size       int64
+	nextRotate time.Time
+	refs       int
+	millCh     chan struct{}
+	millDone   chan struct{}
+}
+
+// newRotateSink 根据形如 rotate:///var/log/app.log?max-size=100 的 URL 打开滚动文件。
+func newRotateSink(u *url.URL) (zap.Sink, error) {
+	if u.Path == "" {
+		return nil, fmt.Errorf("rotate URLs must contain a file path: got %v", u)
+	}
+	cfg, err := parseRotateQuery(u.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return openRotateWriter(u.Path, cfg)
+}
+
+func parseRotateQuery(q url.Values) (rotateConfig, error) {
+	var cfg rotateConfig
+	for key, values := range q {
+		if len(values) == 0 {
+			continue
+		}
+		value := values[len(values)-1]
+		var err error
+		switch key {
+		case "max-size":
+			var n int
+			n, err = strconv.Atoi(value)
+			cfg.maxSize = int64(n) * megabyte
+		case "max-age":
+			var n int
+			n, err = strconv.Atoi(value)
+			cfg.maxAge = time.Duration(n) * 24 * time.Hour
+		case "max-backups":
+			cfg.maxBackups, err = strconv.Atoi(value)
+		case "max-total-size":
+			var n int
+			n, err = strconv.Atoi(value)
+			cfg.maxTotal = int64(n) * megabyte
+		case "compress":
+			cfg.compress = value
+			if _, ok := compressExts[value]; !ok && value != compressNone {
+				err = fmt.Errorf("unknown compression format")
+			}
+		case "rotate-daily":
+			cfg.daily, err = strconv.ParseBool(value)
+		case "local-time":
+			cfg.localTime, err = strconv.ParseBool(value)
+		default:
+			err = fmt.Errorf("unknown parameter")
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("invalid rotate parameter %s=%q: %v", key, value, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// openRotateWriter 返回 filename 对应的 rotateWriter，如果已经打开则更新其滚动策略并复用。
+func openRotateWriter(filename string, cfg rotateConfig) (*rotateWriter, error) {
+	rotators.Lock()
+	defer rotators.Unlock()
+
+	if w, ok := rotators.m[filename]; ok {
+		w.mu.Lock()
+		w.cfg = cfg
+		w.nextRotate = w.nextRotateTime(currentTime())
+		w.mu.Unlock()
+		w.refs++
+
+		return w, nil
+	}
+
+	w := &rotateWriter{filename: filename, cfg: cfg}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.openExistingOrNew(); err != nil {
+		return nil, err
+	}
+	// 启动时也执行一次清理，重启后的服务同样会遵守保留策略
+	w.mill()
+	w.refs = 1
+	rotators.m[filename] = w
+
+	return w, nil
+}
+
+// Write 实现 io.Writer，必要时先滚动文件再写入。
+func (w *rotateWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err = w.openExistingOrNew(); err != nil {
+			return 0, err
+		}
+	}
+
+	// 滚动失败时仍然写入当前文件，并返回滚动失败的原因
+	var rotateErr error
+	if w.shouldRotate(int64(len(p))) {
+		if rotateErr = w.rotate(); w.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+
+	return n, err
+}
+
+// Sync 实现 zapcore.WriteSyncer。
+func (w *rotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	return w.file.Sync()
+}
+
+// Close 实现 zap.Sink，最后一个使用者关闭时才真正关闭文件，并等待后台的压缩和清理结束。
+func (w *rotateWriter) Close() error {
+	rotators.Lock()
+	defer rotators.Unlock()
+
+	w.refs--
+	if w.refs > 0 {
+		return nil
+	}
+	delete(rotators.m, w.filename)
+
+	w.mu.Lock()
+	done := w.millDone
+	if w.millCh != nil {
+		close(w.millCh)
+		w.millCh, w.millDone = nil, nil
+	}
+	err := w.closeFile()
+	w.mu.Unlock()
+
+	// millRun 需要获取 w.mu，释放锁后再等待
+	if done != nil {
+		<-done
+	}
+
+	return err
+}
+
+func (w *rotateWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+
+	return err
+}
+
+func (w *rotateWriter) shouldRotate(writeLen int64) bool {
+	if w.cfg.daily && !currentTime().Before(w.nextRotate) {
+		return true
+	}
+
+	return w.cfg.maxSize > 0 && w.size > 0 && w.size+writeLen > w.cfg.maxSize
+}
+
+// openExistingOrNew 打开已有的日志文件继续追加，如果该文件已经跨天则先滚动。
+func (w *rotateWriter) openExistingOrNew() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0o755); err != nil {
+		return fmt.Errorf("can't make directories for new logfile: %v", err)
+	}
+
+	info, err := os.Stat(w.filename)
+	if os.IsNotExist(err) {
+		return w.openNew()
+	}
+	if err != nil {
+		return fmt.Errorf("error getting log file info: %v", err)
+	}
+
+	now := currentTime()
+	if w.cfg.daily && info.ModTime().Before(w.lastMidnight(now)) {
+		// 滚动失败时已经以追加模式打开了当前文件，下次滚动时再重试
+		if err = w.rotate(); w.file == nil {
+			return err
+		}
+
+		return nil
+	}
+
+	file, err := os.OpenFile(w.filename, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return w.openNew()
+	}
+	w.file = file
+	w.size = info.Size()
+	w.nextRotate = w.nextRotateTime(now)
+
+	return nil
+}
+
+func (w *rotateWriter) openNew() error {
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't open new logfile: %v", err)
+	}
+	w.file = file
+	w.size = 0
+	w.nextRotate = w.nextRotateTime(currentTime())
+
+	return nil
+}
+
+// rotate 将当前文件重命名为带时间戳的备份，并打开一个新文件。
+// 失败时以追加模式重新打开当前文件，之后的日志继续写入当前文件。
+func (w *rotateWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return w.reopen(err)
+	}
+
+	if _, err := os.Stat(w.filename); err == nil {
+		backup, err := w.backupName(currentTime())
+		if err != nil {
+			return w.reopen(err)
+		}
+		if err = os.Rename(w.filename, backup); err != nil {
+			return w.reopen(fmt.Errorf("can't rename log file: %v", err))
+		}
+	}
+
+	if err := w.openNew(); err != nil {
+		return w.reopen(err)
+	}
+	w.mill()
+
+	return nil
+}
+
+// reopen 在滚动失败后以追加模式打开当前文件，返回滚动失败的原因 cause。
+func (w *rotateWriter) reopen(cause error) error {
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("%v; can't reopen log file: %v", cause, err)
+	}
+	w.file = file
+	if info, err := file.Stat(); err == nil {
+		w.size = info.Size()
+	}
+	w.nextRotate = w.nextRotateTime(currentTime())
+
+	return cause
+}
+
+// backupName 生成备份文件名，如 /var/log/app-2006-01-02T15-04-05.000.log。
+func (w *rotateWriter) backupName(t time.Time) (string, error) {
+	dir := filepath.Dir(w.filename)
+	prefix, ext := w.prefixAndExt()
+	if !w.cfg.localTime {
+		t = t.UTC()
+	}
+
+	for {
+		name := filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+		_, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			return name, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error getting backup file info: %v", err)
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func (w *rotateWriter) prefixAndExt() (prefix, ext string) {
+	name := filepath.Base(w.filename)
+	ext = filepath.Ext(name)
+
+	return name[:len(name)-len(ext)] + "-", ext
+}
+
+func (w *rotateWriter) location() *time.Location {
+	if w.cfg.localTime {
+		return time.Local
+	}
+
+	return time.UTC
+}
+
+func (w *rotateWriter) lastMidnight(now time.Time) time.Time {
+	now = now.In(w.location())
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, w.location())
+}
+
+func (w *rotateWriter) nextRotateTime(now time.Time) time.Time {
+	if !w.cfg.daily {
+		return time.Time{}
+	}
+
+	return w.lastMidnight(now).AddDate(0, 0, 1)
+}
+
+// mill 通知后台 goroutine 压缩并清理备份文件，没有配置压缩和清理时不启动 goroutine，调用方需持有 w.mu。
+func (w *rotateWriter) mill() {
+	if !w.cfg.millEnabled() {
+		return
+	}
+	if w.millCh == nil {
+		w.millCh = make(chan struct{}, 1)
+		w.millDone = make(chan struct{})
+		go func(ch <-chan struct{}, done chan<- struct{}) {
+			defer close(done)
+			for range ch {
+				_ = w.millRun()
+			}
+		}(w.millCh, w.millDone)
+	}
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// backupFile 一个已滚动的备份文件。
+type backupFile struct {
+	path       string
+	timestamp  time.Time
+	size       int64
+	compressed bool
+}
+
+// oldBackups 按时间从新到旧返回所有备份文件，包括已压缩的文件。
+func (w *rotateWriter) oldBackups() ([]backupFile, error) {
+	dir := filepath.Dir(w.filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("can't read log file directory: %v", err)
+	}
+
+	prefix, ext := w.prefixAndExt()
+	var backups []backupFile
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		ts, compressed, ok := trimBackupName(name, prefix, ext)
+		if !ok {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, ts, w.location())
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{
+			path:       filepath.Join(dir, name),
+			timestamp:  t,
+			size:       info.Size(),
+			compressed: compressed,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+
+	return backups, nil
+}
+
+// trimBackupName 从备份文件名中取出时间戳部分。
+func trimBackupName(name, prefix, ext string) (ts string, compressed, ok bool) {
+	if !strings.HasPrefix(name, prefix) {
+		return "", false, false
+	}
+	name = name[len(prefix):]
+	for _, cext := range compressExts {
+		if strings.HasSuffix(name, ext+cext) {
+			return name[:len(name)-len(ext+cext)], true, true
+		}
+	}
+	if strings.HasSuffix(name, ext) {
+		return name[:len(name)-len(ext)], false, true
+	}
+
+	return "", false, false
+}
+
+// millRun 压缩备份文件，并按数量、时间和总大小删除多余的备份文件。
+func (w *rotateWriter) millRun() error {
+	w.mu.Lock()
+	cfg := w.cfg
+	current := w.size
+	w.mu.Unlock()
+
+	if !cfg.millEnabled() {
+		return nil
+	}
+
+	backups, err := w.oldBackups()
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	remove := func(b backupFile) {
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	cutoff := currentTime().Add(-cfg.maxAge)
+	remaining := backups[:0]
+	for i, b := range backups {
+		if (cfg.maxBackups > 0 && i >= cfg.maxBackups) || (cfg.maxAge > 0 && b.timestamp.Before(cutoff)) {
+			remove(b)
+			continue
+		}
+		remaining = append(remaining, b)
+	}
+
+	if cext, ok := compressExts[cfg.compress]; ok {
+		for i, b := range remaining {
+			if b.compressed {
+				continue
+			}
+			dst := b.path + cext
+			if err := compressFile(b.path, dst, cfg.compress); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if info, err := os.Stat(dst); err == nil {
+				remaining[i] = backupFile{path: dst, timestamp: b.timestamp, size: info.Size(), compressed: true}
+			}
+		}
+	}
+
+	if cfg.maxTotal > 0 {
+		total := current
+		for _, b := range remaining {
+			total += b.size
+			if total > cfg.maxTotal {
+				remove(b)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to mill old log files: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,379 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countLines(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+
+	lines := 0
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		assert.NoError(t, err)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines++
+		}
+		_ = f.Close()
+	}
+
+	return lines
+}
+
+func Test_rotateWriter_Write(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       rotateConfig
+		writes    int
+		wantFiles int
+	}{
+		{
+			name:      "no rotate",
+			cfg:       rotateConfig{},
+			writes:    3,
+			wantFiles: 1,
+		},
+		{
+			name:      "rotate by size",
+			cfg:       rotateConfig{maxSize: 10},
+			writes:    3,
+			wantFiles: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := openRotateWriter(filepath.Join(dir, "app.log"), tt.cfg)
+			assert.NoError(t, err)
+			defer w.Close()
+
+			for i := 0; i < tt.writes; i++ {
+				n, err := w.Write([]byte("hello\n"))
+				assert.NoError(t, err)
+				assert.Equal(t, 6, n)
+			}
+
+			entries, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			assert.Len(t, entries, tt.wantFiles)
+			assert.Equal(t, tt.writes, countLines(t, dir))
+		})
+	}
+}
+
+func Test_rotateWriter_daily(t *testing.T) {
+	now := time.Date(2023, 4, 1, 23, 59, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = time.Now }()
+
+	dir := t.TempDir()
+	w, err := openRotateWriter(filepath.Join(dir, "app.log"), rotateConfig{daily: true})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("before midnight\n"))
+	assert.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = w.Write([]byte("after midnight\n"))
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "app-2023-04-02T00-01-00.000.log"))
+	assert.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "after midnight\n", string(data))
+}
+
+func Test_rotateWriter_rotateError(t *testing.T) {
+	// 备份文件名超过文件系统的长度限制，滚动总是失败
+	dir := t.TempDir()
+	filename := filepath.Join(dir, strings.Repeat("a", 240)+".log")
+	w, err := openRotateWriter(filename, rotateConfig{maxSize: 10})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		n, err := w.Write([]byte("hello\n"))
+		assert.Equal(t, 6, n)
+		if i > 0 {
+			assert.Error(t, err)
+		}
+	}
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("hello\n", 3), string(data))
+}
+
+func Test_rotateWriter_millRun(t *testing.T) {
+	now := time.Date(2023, 4, 10, 0, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = time.Now }()
+
+	tests := []struct {
+		name string
+		cfg  rotateConfig
+		want []string
+	}{
+		{
+			name: "max backups",
+			cfg:  rotateConfig{maxBackups: 2},
+			want: []string{"app-2023-04-09T00-00-00.000.log", "app-2023-04-08T00-00-00.000.log"},
+		},
+		{
+			name: "max age",
+			cfg:  rotateConfig{maxAge: 48 * time.Hour},
+			want: []string{
+				"app-2023-04-09T00-00-00.000.log",
+				"app-2023-04-08T00-00-00.000.log",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, day := range []int{5, 7, 8, 9} {
+				name := fmt.Sprintf("app-2023-04-%02dT00-00-00.000.log", day)
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0o644))
+			}
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "other.log"), []byte("x\n"), 0o644))
+
+			w := &rotateWriter{filename: filepath.Join(dir, "app.log"), cfg: tt.cfg}
+			assert.NoError(t, w.millRun())
+
+			backups, err := w.oldBackups()
+			assert.NoError(t, err)
+			var got []string
+			for _, b := range backups {
+				got = append(got, filepath.Base(b.path))
+			}
+			assert.Equal(t, tt.want, got)
+			_, err = os.Stat(filepath.Join(dir, "other.log"))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_rotateWriter_millRun_compress(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  rotateConfig
+		want []string
+	}{
+		{
+			name: "gzip",
+			cfg:  rotateConfig{compress: compressGzip},
+			want: []string{
+				"app-2023-04-09T00-00-00.000.log.gz",
+				"app-2023-04-08T00-00-00.000.log.gz",
+				"app-2023-04-07T00-00-00.000.log.gz",
+			},
+		},
+		{
+			name: "zstd with max total size",
+			cfg:  rotateConfig{compress: compressZstd, maxTotal: 1},
+			want: []string{},
+		},
+		{
+			name: "max total size",
+			cfg:  rotateConfig{maxTotal: 2010},
+			want: []string{
+				"app-2023-04-09T00-00-00.000.log",
+				"app-2023-04-08T00-00-00.000.log.gz",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			content := []byte(strings.Repeat("x", 1000))
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "app-2023-04-09T00-00-00.000.log"), content, 0o644))
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "app-2023-04-07T00-00-00.000.log"), content, 0o644))
+			assert.NoError(t, compressFile(
+				filepath.Join(dir, "app-2023-04-07T00-00-00.000.log"),
+				filepath.Join(dir, "app-2023-04-07T00-00-00.000.log.gz"),
+				compressGzip,
+			))
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "app-2023-04-08T00-00-00.000.log.gz"), content, 0o644))
+
+			w := &rotateWriter{filename: filepath.Join(dir, "app.log"), cfg: tt.cfg}
+			assert.NoError(t, w.millRun())
+
+			backups, err := w.oldBackups()
+			assert.NoError(t, err)
+			got := []string{}
+			for _, b := range backups {
+				got = append(got, filepath.Base(b.path))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_openRotateWriter_startup(t *testing.T) {
+	dir := t.TempDir()
+	for _, day := range []int{5, 7, 8, 9} {
+		name := fmt.Sprintf("app-2023-04-%02dT00-00-00.000.log", day)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0o644))
+	}
+
+	w, err := openRotateWriter(filepath.Join(dir, "app.log"), rotateConfig{maxBackups: 1})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	assert.Eventually(t, func() bool {
+		backups, err := w.oldBackups()
+		return err == nil && len(backups) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_rotateWriter_Close_stopsMill(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openRotateWriter(filepath.Join(dir, "app.log"), rotateConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, w.millCh, "no mill goroutine without compression or retention")
+	assert.NoError(t, w.Close())
+
+	w, err = openRotateWriter(filepath.Join(dir, "app.log"), rotateConfig{maxBackups: 1})
+	assert.NoError(t, err)
+	done := w.millDone
+	if !assert.NotNil(t, done) {
+		return
+	}
+	assert.NoError(t, w.Close())
+
+	select {
+	case <-done:
+	default:
+		t.Fatal("mill goroutine is still running after Close")
+	}
+}
+
+func Test_rotateWriter_concurrent(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openRotateWriter(filepath.Join(dir, "app.log"), rotateConfig{maxSize: 1024})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	const goroutines, lines = 8, 200
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < lines; j++ {
+				_, _ = fmt.Fprintf(w, "goroutine %d line %d\n", i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, goroutines*lines, countLines(t, dir))
+}
+
+func Test_openRotateWriter_shared(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	w1, err := openRotateWriter(filename, rotateConfig{})
+	assert.NoError(t, err)
+	w2, err := openRotateWriter(filename, rotateConfig{maxSize: 10})
+	assert.NoError(t, err)
+
+	assert.Same(t, w1, w2)
+	assert.Equal(t, int64(10), w1.cfg.maxSize)
+
+	assert.NoError(t, w1.Close())
+	_, err = w2.Write([]byte("still open\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w2.Close())
+	assert.Nil(t, w2.file)
+}
+
+func Test_parseRotateQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    rotateConfig
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:  "all",
+			query: "max-size=1&max-age=2&max-backups=3&max-total-size=4&rotate-daily=true&local-time=true&compress=gzip",
+			want: rotateConfig{
+				maxSize:    megabyte,
+				maxAge:     48 * time.Hour,
+				maxBackups: 3,
+				maxTotal:   4 * megabyte,
+				daily:      true,
+				localTime:  true,
+				compress:   compressGzip,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "invalid",
+			query:   "max-size=abc",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid compress",
+			query:   "compress=lz4",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "unknown",
+			query:   "foo=bar",
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			got, err := parseRotateQuery(q)
+			if !tt.wantErr(t, err) {
+				return
+			}
+			if err == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNew_rotate(t *testing.T) {
+	dir := t.TempDir()
+	opts := NewOptions()
+	opts.OutputPaths = []string{filepath.Join(dir, "app.log")}
+	opts.MaxSize = 1
+	opts.MaxBackups = 1
+
+	l := New(opts)
+	for i := 0; i < 10; i++ {
+		l.Info("hello", String("i", fmt.Sprint(i)))
+	}
+	l.Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, strings.Count(string(data), "hello"))
+
+	rotators.Lock()
+	w := rotators.m[filepath.Join(dir, "app.log")]
+	rotators.Unlock()
+	if assert.NotNil(t, w) {
+		assert.NoError(t, w.Close())
+	}
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 采样配置的默认值，Options 中对应字段为零值时使用。
+const (
+	defaultSamplingTick       = time.Second
+	defaultSamplingInitial    = 100
+	defaultSamplingThereafter = 100
+)
+
+// droppedSamples 记录所有 logger 因采样而丢弃的日志条数。
+var droppedSamples uint64
+
+// DroppedSamples 返回自进程启动以来因采样而丢弃的日志条数。
+func DroppedSamples() uint64 {
+	return atomic.LoadUint64(&droppedSamples)
+}
+
+// samplingCore 只对低于 exempt 级别的日志进行采样，exempt 及以上级别的日志全部输出。
+type samplingCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+	exempt  zapcore.Level
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{
+		Core:    c.Core.With(fields),
+		sampled: c.sampled.With(fields),
+		exempt:  c.exempt,
+	}
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= c.exempt {
+		return c.Core.Check(ent, ce)
+	}
+
+	return c.sampled.Check(ent, ce)
+}
+
+// samplingOption 根据 Options 返回采样相关的 zap.Option。
+func (o *Options) samplingOption() zap.Option {
+	if o.DisableSampling {
+		return zap.WrapCore(func(core zapcore.Core) zapcore.Core { return core })
+	}
+
+	exempt := zapcore.ErrorLevel
+	if o.SamplingExemptLevel != "" {
+		if err := exempt.UnmarshalText([]byte(o.SamplingExemptLevel)); err != nil {
+			exempt = zapcore.ErrorLevel
+		}
+	}
+
+	tick, initial, thereafter := o.SamplingTick, o.SamplingInitial, o.SamplingThereafter
+	if tick <= 0 {
+		tick = defaultSamplingTick
+	}
+	if initial <= 0 {
+		initial = defaultSamplingInitial
+	}
+	if thereafter <= 0 {
+		thereafter = defaultSamplingThereafter
+	}
+
+	hook := o.SamplingHook
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		sampled := zapcore.NewSamplerWithOptions(
+			core,
+			tick,
+			initial,
+			thereafter,
+			zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+				if dec&zapcore.LogDropped != 0 {
+					atomic.AddUint64(&droppedSamples, 1)
+				}
+				if hook != nil {
+					hook(ent, dec)
+				}
+			}),
+		)
+
+		return &samplingCore{Core: core, sampled: sampled, exempt: exempt}
+	})
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestOptions_samplingOption(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(o *Options)
+		level       zapcore.Level
+		wantLogged  int
+		wantDropped int
+	}{
+		{
+			name:        "default",
+			modify:      func(o *Options) {},
+			level:       zapcore.InfoLevel,
+			wantLogged:  100,
+			wantDropped: 50,
+		},
+		{
+			name:       "error is never sampled",
+			modify:     func(o *Options) {},
+			level:      zapcore.ErrorLevel,
+			wantLogged: 150,
+		},
+		{
+			name: "exempt level",
+			modify: func(o *Options) {
+				o.SamplingExemptLevel = "info"
+			},
+			level:      zapcore.InfoLevel,
+			wantLogged: 150,
+		},
+		{
+			name: "custom initial and thereafter",
+			modify: func(o *Options) {
+				o.SamplingInitial = 10
+				o.SamplingThereafter = 20
+			},
+			level:       zapcore.WarnLevel,
+			wantLogged:  17,
+			wantDropped: 133,
+		},
+		{
+			name: "disable sampling",
+			modify: func(o *Options) {
+				o.DisableSampling = true
+			},
+			level:      zapcore.InfoLevel,
+			wantLogged: 150,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			tt.modify(o)
+			dropped := 0
+			o.SamplingHook = func(_ zapcore.Entry, dec zapcore.SamplingDecision) {
+				if dec&zapcore.LogDropped != 0 {
+					dropped++
+				}
+			}
+			before := DroppedSamples()
+
+			core, logs := observer.New(zapcore.DebugLevel)
+			l := zap.New(core, o.samplingOption()).With(zap.String("k", "v"))
+			for i := 0; i < 150; i++ {
+				l.Check(tt.level, "sampled").Write()
+			}
+
+			assert.Equal(t, tt.wantLogged, logs.Len())
+			assert.Equal(t, tt.wantDropped, dropped)
+			assert.Equal(t, uint64(tt.wantDropped), DroppedSamples()-before)
+		})
+	}
+}
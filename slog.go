@@ -0,0 +1,228 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	setSlogDefault = func(l *zap.Logger) (restore func()) {
+		prev := slog.Default()
+		slog.SetDefault(slog.New(&slogHandler{zap: l}))
+
+		return func() { slog.SetDefault(prev) }
+	}
+}
+
+// slogHandler 是使用本包 Logger 记录日志的 slog.Handler。
+type slogHandler struct {
+	// zap 在 Logger 由本包创建时使用，可以保留 slog.Record 中的时间和调用位置。
+	zap *zap.Logger
+	// logger 和 fields 在其他 Logger 实现上使用，WithAttrs 添加的字段保存在 fields 中。
+	logger Logger
+	fields []Field
+	// groups 是 WithGroup 打开但还没有添加任何字段的 group。
+	groups []string
+}
+
+var _ slog.Handler = &slogHandler{}
+
+// NewSlogHandler 返回使用 logger 记录日志的 slog.Handler，slog 的日志级别按以下规则转换：
+// 低于 slog.LevelInfo 为 Debug，低于 slog.LevelWarn 为 Info，低于 slog.LevelError 为 Warn，其余为 Error。
+// ContextExtractor 从 context 中提取的字段同样会添加到日志中。
+func NewSlogHandler(logger Logger) slog.Handler {
+	if l, ok := logger.(*zapLogger); ok {
+		return &slogHandler{zap: l.zapLogger}
+	}
+
+	return &slogHandler{logger: logger}
+}
+
+// Slog 返回使用全局 logger 记录日志的 slog.Logger。
+func Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(std()))
+}
+
+// slogLevel 返回 slog 日志级别对应的 Zap 级别。
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// contextLevelEnabler 由可以判断各个日志级别是否启用的 Logger 实现，如 FromSlog 返回的 Logger。
+type contextLevelEnabler interface {
+	enabled(ctx context.Context, level zapcore.Level) bool
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	lvl := slogLevel(level)
+	if h.zap != nil {
+		return h.zap.Core().Enabled(lvl)
+	}
+	if l, ok := h.logger.(contextLevelEnabler); ok {
+		return l.enabled(ctx, lvl)
+	}
+
+	// 其他 Logger 实现只能通过 Enabled 知道 Info 级别是否启用，Info 未启用时 Debug 同样未启用
+	return lvl > zapcore.InfoLevel || h.logger.Enabled()
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, a)
+		return true
+	})
+	fields = append(contextFields(ctx), h.groupFields(fields)...)
+
+	if h.zap == nil {
+		h.write(slogLevel(r.Level), r.Message, append(h.fields[:len(h.fields):len(h.fields)], fields...))
+
+		return nil
+	}
+
+	ce := h.zap.Check(slogLevel(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		ce.Time = r.Time
+	}
+	// zap 记录的调用位置在 slog 内部，替换为 slog.Record 中的调用位置
+	if ce.Caller.Defined && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, frame.PC != 0)
+		ce.Caller.Function = frame.Function
+	}
+	ce.Write(fields...)
+
+	return nil
+}
+
+// write 使用其他 Logger 实现记录日志。
+func (h *slogHandler) write(level zapcore.Level, msg string, fields []Field) {
+	switch level {
+	case zapcore.DebugLevel:
+		h.logger.Debug(msg, fields...)
+	case zapcore.InfoLevel:
+		h.logger.Info(msg, fields...)
+	case zapcore.WarnLevel:
+		h.logger.Warn(msg, fields...)
+	default:
+		h.logger.Error(msg, fields...)
+	}
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []Field
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+
+	fields = h.groupFields(fields)
+	clone := &slogHandler{zap: h.zap, logger: h.logger}
+	if h.zap != nil {
+		clone.zap = h.zap.With(fields...)
+	} else {
+		clone.fields = append(h.fields[:len(h.fields):len(h.fields)], fields...)
+	}
+
+	return clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+
+	return &clone
+}
+
+// groupFields 在 fields 不为空时，将它们放到尚未添加字段的 group 中。
+func (h *slogHandler) groupFields(fields []Field) []Field {
+	if len(fields) == 0 || len(h.groups) == 0 {
+		return fields
+	}
+
+	grouped := make([]Field, 0, len(h.groups)+len(fields))
+	for _, group := range h.groups {
+		grouped = append(grouped, zap.Namespace(group))
+	}
+
+	return append(grouped, fields...)
+}
+
+// appendSlogAttr 将 slog.Attr 转换为 Zap 字段追加到 fields 中，按 slog 的约定忽略空的 Attr 和 group，
+// 并将 key 为空的 group 展开。
+func appendSlogAttr(fields []Field, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			for _, attr := range attrs {
+				fields = appendSlogAttr(fields, attr)
+			}
+
+			return fields
+		}
+
+		return append(fields, zap.Object(a.Key, slogGroup(attrs)))
+	default:
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+// slogGroup 将 slog 的 group 编码为 Zap 对象。
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, a := range g {
+		for _, field := range appendSlogAttr(nil, a) {
+			field.AddTo(enc)
+		}
+	}
+
+	return nil
+}
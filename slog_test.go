@@ -0,0 +1,179 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_slogLevel(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  zapcore.Level
+	}{
+		{level: slog.LevelDebug - 4, want: zapcore.DebugLevel},
+		{level: slog.LevelDebug, want: zapcore.DebugLevel},
+		{level: slog.LevelInfo, want: zapcore.InfoLevel},
+		{level: slog.LevelInfo + 2, want: zapcore.InfoLevel},
+		{level: slog.LevelWarn, want: zapcore.WarnLevel},
+		{level: slog.LevelError, want: zapcore.ErrorLevel},
+		{level: slog.LevelError + 4, want: zapcore.ErrorLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, slogLevel(tt.level))
+		})
+	}
+}
+
+func TestNewSlogHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want map[string]interface{}
+	}{
+		{
+			name: "attrs",
+			log: func(l *slog.Logger) {
+				l.Info("msg", "string", "value", "int", 1, "bool", true, "duration", time.Second)
+			},
+			want: map[string]interface{}{"string": "value", "int": int64(1), "bool": true, "duration": time.Second},
+		},
+		{
+			name: "with attrs",
+			log: func(l *slog.Logger) {
+				l.With("key", "value").Info("msg")
+			},
+			want: map[string]interface{}{"key": "value"},
+		},
+		{
+			name: "group",
+			log: func(l *slog.Logger) {
+				l.Info("msg", slog.Group("req", "method", "GET"), slog.Group("empty"), slog.Group("", "inline", 1))
+			},
+			want: map[string]interface{}{"req": map[string]interface{}{"method": "GET"}, "inline": int64(1)},
+		},
+		{
+			name: "with group",
+			log: func(l *slog.Logger) {
+				l.WithGroup("a").With("b", 1).WithGroup("c").Info("msg", "d", 2)
+			},
+			want: map[string]interface{}{"a": map[string]interface{}{"b": int64(1), "c": map[string]interface{}{"d": int64(2)}}},
+		},
+		{
+			name: "empty group",
+			log: func(l *slog.Logger) {
+				l.WithGroup("a").Info("msg")
+			},
+			want: map[string]interface{}{},
+		},
+		{
+			name: "context",
+			log: func(l *slog.Logger) {
+				l.InfoContext(context.WithValue(context.Background(), KeyRequestID, "r1"), "msg")
+			},
+			want: map[string]interface{}{"request-id": "r1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			tt.log(slog.New(NewSlogHandler(NewLogger(zap.New(core)))))
+
+			if assert.Equal(t, 1, logs.Len()) {
+				assert.Equal(t, "msg", logs.All()[0].Message)
+				assert.Equal(t, tt.want, logs.All()[0].ContextMap())
+			}
+		})
+	}
+}
+
+func TestNewSlogHandler_level(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core))
+	logger.SetLevel(WarnLevel)
+	h := NewSlogHandler(logger)
+
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+
+	l := slog.New(h)
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	if assert.Equal(t, 2, logs.Len()) {
+		assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+		assert.Equal(t, zapcore.ErrorLevel, logs.All()[1].Level)
+	}
+}
+
+func TestNewSlogHandler_levelOtherLogger(t *testing.T) {
+	sl := slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := FromSlog(sl)
+	logger.SetLevel(WarnLevel)
+	h := NewSlogHandler(logger)
+
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+
+	h = NewSlogHandler(&disabledLogger{Logger: logger})
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+}
+
+// disabledLogger 隐藏 Logger 的实现类型，只通过 Enabled 报告 Info 级别未启用。
+type disabledLogger struct {
+	Logger
+}
+
+func (l *disabledLogger) Enabled() bool { return false }
+
+func TestNewSlogHandler_caller(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	opts := NewOptions()
+	opts.Format = jsonFormat
+	opts.OutputPaths = []string{file}
+	l := New(opts)
+
+	slog.New(NewSlogHandler(l)).Info("info")
+	l.Flush()
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "/slog_test.go:")
+	assert.NoError(t, l.Close())
+}
+
+func TestOptions_Build_slogDefault(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+	defer zap.ReplaceGlobals(zap.L())
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	opts := NewOptions()
+	opts.OutputPaths = []string{file}
+	opts.SlogDefault = true
+	assert.Empty(t, opts.Validate())
+	assert.NoError(t, opts.Build())
+
+	slog.Info("from slog", "key", "value")
+	_ = zap.L().Sync()
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), "from slog"))
+}
@@ -0,0 +1,281 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// truncatedSuffix 追加在超过长度限制而被截断的 body 之后。
+const truncatedSuffix = "..."
+
+// maxRetryDrain 重试前最多读取的响应 body 字节数，读完的连接可以复用。
+const maxRetryDrain = 4 << 10
+
+// TransportOption 设置 Transport。
+type TransportOption func(t *loggingTransport)
+
+// TransportStatusLevel 设置按响应状态码选择日志级别的函数，默认 5xx 为 Error，4xx 为 Warn，其余为 Info。
+// 请求失败没有响应时使用 Error 级别。
+func TransportStatusLevel(level func(status int) Level) TransportOption {
+	return func(t *loggingTransport) {
+		if level != nil {
+			t.level = level
+		}
+	}
+}
+
+// TransportRetry 设置请求失败或响应状态码为 429、502、503、504 时最多重试 max 次，
+// 第一次重试前等待 backoff，之后每次等待时间翻倍。与 net/http 相同，只重试幂等的请求，
+// 即 GET、HEAD、OPTIONS、TRACE、PUT、DELETE 或者带有 Idempotency-Key 请求头的请求，并且 body 可以重新获取。
+func TransportRetry(max int, backoff time.Duration) TransportOption {
+	return func(t *loggingTransport) {
+		t.retries = max
+		t.backoff = backoff
+	}
+}
+
+// TransportCaptureBody 设置 logger 启用 Debug 级别时记录请求和响应的 body，每个 body 最多记录 limit 字节，
+// 超过的部分以 ... 代替。响应的 body 在关闭时以 Debug 级别记录。
+func TransportCaptureBody(limit int) TransportOption {
+	return func(t *loggingTransport) { t.bodyLimit = limit }
+}
+
+type loggingTransport struct {
+	base      http.RoundTripper
+	level     func(status int) Level
+	retries   int
+	backoff   time.Duration
+	bodyLimit int
+}
+
+// Transport 返回记录每个请求的 http.RoundTripper，base 为 nil 时使用 http.DefaultTransport。
+//
+// 日志使用 FromContext(req.Context()) 记录，包含 method、url、status、latency 和 retries 字段，
+// url 中查询参数的值和密码已经脱敏。请求的上下文中有 KeyRequestID 时，将其作为 X-Request-ID 请求头转发，
+// 请求中已经设置的 X-Request-ID 不会被覆盖。
+//
+//	client := &http.Client{Transport: log.Transport(nil, log.TransportRetry(2, 100*time.Millisecond))}
+func Transport(base http.RoundTripper, opts ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &loggingTransport{base: base, level: defaultStatusLevel}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	logger := FromContext(ctx)
+
+	// RoundTripper 不能修改传入的请求
+	req = req.Clone(ctx)
+	if id, ok := ctx.Value(KeyRequestID).(string); ok && id != "" && req.Header.Get(HeaderRequestID) == "" {
+		req.Header.Set(HeaderRequestID, id)
+	}
+	capture := t.bodyLimit > 0 && logger.GetLevel() <= DebugLevel
+
+	start := time.Now()
+	var (
+		resp     *http.Response
+		err      error
+		retries  int
+		reqBody  *bodyCapture
+		getErr   error
+		backoff  = t.backoff
+		endpoint = redactURL(req.URL)
+		attempt  = req
+	)
+	for {
+		if capture && attempt.Body != nil && attempt.Body != http.NoBody {
+			reqBody = &bodyCapture{ReadCloser: attempt.Body, limit: t.bodyLimit}
+			attempt.Body = reqBody
+		}
+		resp, err = t.base.RoundTrip(attempt)
+		if retries >= t.retries || !retryable(req, resp, err) {
+			break
+		}
+		if resp != nil {
+			_, _ = io.CopyN(io.Discard, resp.Body, maxRetryDrain)
+			resp.Body.Close()
+		}
+		if !sleepContext(ctx, backoff) {
+			resp, err = nil, ctx.Err()
+			break
+		}
+		backoff *= 2
+		// 与 net/http 相同，每次重试使用新的请求和重新获取的 body，base 可能仍在读取上一次请求的 body
+		attempt = req.Clone(ctx)
+		if req.GetBody != nil {
+			if attempt.Body, getErr = req.GetBody(); getErr != nil {
+				resp, err = nil, getErr
+				break
+			}
+		}
+		retries++
+	}
+
+	fields := []Field{
+		String("method", req.Method),
+		String("url", endpoint),
+	}
+	level := ErrorLevel
+	if resp != nil {
+		fields = append(fields, Int("status", resp.StatusCode))
+		level = t.level(resp.StatusCode)
+	}
+	fields = append(fields, Duration("latency", time.Since(start)), Int("retries", retries))
+	if err != nil {
+		fields = append(fields, Err(err))
+	}
+	logAt(ctx, logger, level, "HTTP client request", fields)
+
+	if capture {
+		bodies := &capturedBodies{logger: logger, method: req.Method, url: endpoint, request: reqBody}
+		if resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+			bodies.log()
+		} else {
+			bodies.response = &bodyCapture{ReadCloser: resp.Body, limit: t.bodyLimit, onClose: bodies.log}
+			resp.Body = bodies.response
+		}
+	}
+
+	return resp, err
+}
+
+// retryable 返回请求是否可以重试：请求是幂等的、body 可以重新获取，并且请求失败或者服务端暂时不可用。
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" && req.Header.Get("X-Idempotency-Key") == "" {
+			return false
+		}
+	}
+
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// sleepContext 等待 d，ctx 结束时返回 false。
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// redactURL 返回隐藏了密码和查询参数值的 URL。
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+
+	cp := *u
+	params := strings.Split(cp.RawQuery, "&")
+	for i, param := range params {
+		if param == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(param, "=")
+		params[i] = key + "=" + redactedValue
+	}
+	cp.RawQuery = strings.Join(params, "&")
+
+	return cp.Redacted()
+}
+
+// bodyCapture 在 body 被读取时记录前 limit 个字节，可以在其他 goroutine 读取的同时获取记录的内容。
+type bodyCapture struct {
+	io.ReadCloser
+	limit   int
+	onClose func()
+
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+	once      sync.Once
+}
+
+func (b *bodyCapture) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.mu.Lock()
+		if remain := b.limit - b.buf.Len(); remain < n {
+			b.buf.Write(p[:remain])
+			b.truncated = true
+		} else {
+			b.buf.Write(p[:n])
+		}
+		b.mu.Unlock()
+	}
+
+	return n, err
+}
+
+func (b *bodyCapture) Close() error {
+	err := b.ReadCloser.Close()
+	if b.onClose != nil {
+		b.once.Do(b.onClose)
+	}
+
+	return err
+}
+
+// String 返回记录的内容，被截断时以 ... 结尾。
+func (b *bodyCapture) String() string {
+	if b == nil {
+		return ""
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.truncated {
+		return b.buf.String() + truncatedSuffix
+	}
+
+	return b.buf.String()
+}
+
+// capturedBodies 一次请求记录的请求和响应 body，响应 body 关闭时以 Debug 级别记录。
+type capturedBodies struct {
+	logger   Logger
+	method   string
+	url      string
+	request  *bodyCapture
+	response *bodyCapture
+}
+
+func (c *capturedBodies) log() {
+	c.logger.Debug("HTTP client body",
+		String("method", c.method),
+		String("url", c.url),
+		String("request-body", c.request.String()),
+		String("response-body", c.response.String()),
+	)
+}
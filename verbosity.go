@@ -0,0 +1,135 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// verbosityKey V(N) 输出的日志中记录详细级别的字段。
+const verbosityKey = "v"
+
+// vmoduleRule 一条按源文件覆盖详细级别的规则。
+type vmoduleRule struct {
+	pattern   string
+	path      bool
+	verbosity int
+}
+
+// match 返回去掉 .go 后缀的源文件路径 file 是否匹配规则。
+// 模式不含 / 时匹配文件名，否则匹配以 / 分隔的任意路径后缀。
+func (r vmoduleRule) match(file string) bool {
+	if !r.path {
+		ok, _ := filepath.Match(r.pattern, filepath.Base(file))
+		return ok
+	}
+
+	for suffix := file; ; {
+		if ok, _ := filepath.Match(r.pattern, suffix); ok {
+			return true
+		}
+		i := strings.IndexByte(suffix, '/')
+		if i < 0 {
+			return false
+		}
+		suffix = suffix[i+1:]
+	}
+}
+
+// vmodule 按调用 V 的源文件覆盖详细级别，匹配结果按调用位置缓存。
+type vmodule struct {
+	rules []vmoduleRule
+	cache sync.Map // uintptr -> int，-1 表示没有匹配的规则
+}
+
+// parseVModule 解析 klog 风格的 vmodule 设置，如 server=2,pkg/db/*=3，text 为空时返回 nil。
+func parseVModule(text string) (*vmodule, error) {
+	var rules []vmoduleRule
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.LastIndexByte(item, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("not a valid vmodule setting: %q", item)
+		}
+		pattern := strings.TrimSuffix(item[:i], ".go")
+		v, err := strconv.Atoi(item[i+1:])
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("not a valid vmodule verbosity: %q", item)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("not a valid vmodule pattern: %q", item)
+		}
+		rules = append(rules, vmoduleRule{pattern: pattern, path: strings.Contains(pattern, "/"), verbosity: v})
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	return &vmodule{rules: rules}, nil
+}
+
+// lookup 返回调用位置 pc 所在的源文件匹配的详细级别，ok 为 false 表示没有匹配的规则。
+func (m *vmodule) lookup(pc uintptr) (v int, ok bool) {
+	if cached, ok := m.cache.Load(pc); ok {
+		v = cached.(int)
+		return v, v >= 0
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	file := strings.TrimSuffix(frame.File, ".go")
+	v = -1
+	for _, rule := range m.rules {
+		if rule.match(file) {
+			v = rule.verbosity
+			break
+		}
+	}
+	m.cache.Store(pc, v)
+
+	return v, v >= 0
+}
+
+// verbosity 返回 l 启用的最大详细级别，设置了 vmodule 时按调用 V 的源文件覆盖。
+// 必须由 V 方法或 V 函数通过 l.v 调用，以找到正确的调用位置。
+func (l *zapLogger) verbosity() int {
+	v := 0
+	if l.logLevel != nil {
+		v = l.logLevel.Verbosity()
+	}
+	if l.levels == nil {
+		return v
+	}
+	if m := l.levels.getVModule(); m != nil {
+		var pcs [1]uintptr
+		// 跳过 runtime.Callers、verbosity、v 以及 V
+		if runtime.Callers(4, pcs[:]) > 0 {
+			if mv, ok := m.lookup(pcs[0]); ok {
+				v = mv
+			}
+		}
+	}
+
+	return v
+}
+
+// v 返回详细级别为 level 的 InfoLogger，logger 启用 Info 级别并且 level 不大于生效的详细级别时启用，
+// 记录的日志以 Info 级别输出，并带有记录详细级别的 v 字段。
+func (l *zapLogger) v(level int) InfoLogger {
+	if level < 0 {
+		level = 0
+	}
+	if !l.zapLogger.Core().Enabled(InfoLevel) || level > l.verbosity() {
+		return disabledInfoLogger
+	}
+
+	return &infoLogger{
+		level: InfoLevel,
+		log:   l.zapLogger.With(Int(verbosityKey, level)),
+	}
+}
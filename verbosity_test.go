@@ -0,0 +1,192 @@
+package log
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseVModule(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []vmoduleRule
+		wantErr bool
+	}{
+		{name: "empty", text: " , "},
+		{
+			name: "valid",
+			text: "server=2, pkg/db/*=3,handler.go=1",
+			want: []vmoduleRule{
+				{pattern: "server", verbosity: 2},
+				{pattern: "pkg/db/*", path: true, verbosity: 3},
+				{pattern: "handler", verbosity: 1},
+			},
+		},
+		{name: "missing verbosity", text: "server", wantErr: true},
+		{name: "missing pattern", text: "=2", wantErr: true},
+		{name: "negative", text: "server=-1", wantErr: true},
+		{name: "bad pattern", text: "serv[=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVModule(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got.rules)
+		})
+	}
+}
+
+func Test_vmoduleRule_match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{pattern: "server", file: "/src/app/server", want: true},
+		{pattern: "serv*", file: "/src/app/server", want: true},
+		{pattern: "server", file: "/src/app/servers", want: false},
+		{pattern: "app/*", file: "/src/app/server", want: true},
+		{pattern: "pkg/db/*", file: "/src/pkg/db/conn", want: true},
+		{pattern: "pkg/db/*", file: "/src/pkg/dbx/conn", want: false},
+		{pattern: "/src/*/server", file: "/src/app/server", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.file, func(t *testing.T) {
+			m, err := parseVModule(tt.pattern + "=1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, m.rules[0].match(tt.file))
+		})
+	}
+}
+
+func newVerbosityLogger(t *testing.T, modify func(o *Options)) (*zapLogger, func() []map[string]interface{}) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	opts := NewOptions()
+	opts.Format = jsonFormat
+	opts.OutputPaths = []string{file}
+	if modify != nil {
+		modify(opts)
+	}
+	l := New(opts)
+	t.Cleanup(func() { _ = l.Close() })
+
+	return l, func() []map[string]interface{} {
+		l.Flush()
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+
+		var entries []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			entry := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+			entries = append(entries, entry)
+		}
+
+		return entries
+	}
+}
+
+func Test_zapLogger_V_verbosity(t *testing.T) {
+	l, entries := newVerbosityLogger(t, func(o *Options) { o.Verbosity = 2 })
+
+	l.V(0).Info("v0")
+	l.V(2).Infow("v2", "k", "v")
+	l.V(3).Info("v3")
+	assert.True(t, l.WithName("db").V(2).Enabled())
+	assert.False(t, l.V(3).Enabled())
+
+	got := entries()
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "INFO", got[0]["level"])
+		assert.Equal(t, float64(0), got[0]["v"])
+		assert.Equal(t, "v2", got[1]["message"])
+		assert.Equal(t, float64(2), got[1]["v"])
+		assert.Equal(t, "v", got[1]["k"])
+	}
+}
+
+func Test_zapLogger_V_level(t *testing.T) {
+	l, _ := newVerbosityLogger(t, func(o *Options) {
+		o.Level = "warn"
+		o.Verbosity = 5
+	})
+
+	assert.False(t, l.V(0).Enabled(), "V logs are written at info level")
+	l.SetLevel(DebugLevel)
+	assert.True(t, l.V(5).Enabled())
+}
+
+func Test_zapLogger_V_vmodule(t *testing.T) {
+	l, entries := newVerbosityLogger(t, func(o *Options) {
+		o.Verbosity = 1
+		o.VModule = "other=9,verbosity_test=4"
+	})
+
+	l.V(4).Info("method")
+	l.WithName("db").V(4).Info("named")
+	l.V(5).Info("disabled")
+
+	defer ReplaceGlobal(l)()
+	V(4).Info("global")
+
+	var messages []string
+	for _, e := range entries() {
+		messages = append(messages, e["message"].(string))
+	}
+	assert.Equal(t, []string{"method", "named", "global"}, messages)
+
+	other, _ := newVerbosityLogger(t, func(o *Options) {
+		o.Verbosity = 1
+		o.VModule = "other=9"
+	})
+	assert.False(t, other.V(2).Enabled())
+}
+
+func Test_zapLogger_V_reload(t *testing.T) {
+	l, _ := newVerbosityLogger(t, nil)
+	assert.False(t, l.V(1).Enabled())
+
+	next := NewOptions()
+	next.Verbosity = 1
+	assert.NoError(t, l.reload(next))
+	assert.True(t, l.V(1).Enabled())
+	assert.False(t, l.V(3).Enabled())
+
+	next = NewOptions()
+	next.VModule = "verbosity_test=3"
+	assert.NoError(t, l.reload(next))
+	assert.True(t, l.V(3).Enabled())
+}
+
+func TestOptions_AddFlags_verbosity(t *testing.T) {
+	o := NewOptions()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	o.AddFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"-v=3", "--log.vmodule=server=5"}))
+	assert.Equal(t, 3, o.Verbosity)
+	assert.Equal(t, "server=5", o.VModule)
+	assert.Empty(t, o.Validate())
+
+	o.Verbosity = -1
+	o.VModule = "server"
+	assert.Len(t, o.Validate(), 2)
+}